@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change
+const diffContext = 2
+
+// lineDiff returns a unified-style diff of two texts or an empty string if they are equal
+func lineDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(oldText, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(newText, "\n"), "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Walk the table into a list of operations
+	type op struct {
+		prefix byte
+		line   string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		}
+	}
+
+	// Print changed lines with context
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	last := -1
+	for k, o := range ops {
+		if o.prefix == ' ' && !nearChange(len(ops), k, func(n int) bool { return ops[n].prefix != ' ' }) {
+			continue
+		}
+		if last >= 0 && k > last+1 {
+			out.WriteString("@@\n")
+		}
+		fmt.Fprintf(&out, "%c%s\n", o.prefix, o.line)
+		last = k
+	}
+	return out.String()
+}
+
+// nearChange reports whether a changed line is within diffContext of index k
+func nearChange(count, k int, changed func(int) bool) bool {
+	for n := k - diffContext; n <= k+diffContext; n++ {
+		if n >= 0 && n < count && changed(n) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Import paths used by generated code
+const (
+	importProffixrest = "github.com/pitwch/go-wrapper-proffix-restapi/proffixrest"
+	importPxtime      = "github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+	importDecimal     = "github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+)
+
+// genConfig configures the generated file
+type genConfig struct {
+	Package  string // Go package name
+	TypeName string // name of the root struct
+	Endpoint string // endpoint for the Resource binding; no binding if empty
+	Source   string // description of the sample source for the header
+}
+
+// generator renders shapes into Go type definitions
+type generator struct {
+	cfg     genConfig
+	defs    []string
+	used    map[string]bool
+	imports map[string]bool
+}
+
+// generate renders the Go source for the inferred root shape
+func generate(cfg genConfig, root *shape) ([]byte, error) {
+	g := &generator{cfg: cfg, used: map[string]bool{}, imports: map[string]bool{}}
+	g.structType(cfg.TypeName, root)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by proffix-structgen from %s; DO NOT EDIT.\n\n", cfg.Source)
+	fmt.Fprintf(&buf, "package %s\n\n", cfg.Package)
+
+	if cfg.Endpoint != "" {
+		g.imports[importProffixrest] = true
+	}
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for p := range g.imports {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		buf.WriteString("import (\n")
+		for _, p := range paths {
+			fmt.Fprintf(&buf, "\t%q\n", p)
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	for _, def := range g.defs {
+		buf.WriteString(def)
+		buf.WriteString("\n")
+	}
+
+	if cfg.Endpoint != "" {
+		fmt.Fprintf(&buf, "// New%[1]sResource binds %[1]s to endpoint %[2]s.\n", cfg.TypeName, cfg.Endpoint)
+		fmt.Fprintf(&buf, "func New%[1]sResource(c *proffixrest.Client) *proffixrest.Resource[%[1]s] {\n", cfg.TypeName)
+		fmt.Fprintf(&buf, "\treturn proffixrest.NewResource[%s](c, %q)\n}\n", cfg.TypeName, cfg.Endpoint)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// structType renders a struct definition for an object shape and returns its name
+func (g *generator) structType(name string, s *shape) string {
+	name = g.uniqueName(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s was generated from %s.\n", name, g.cfg.Source)
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+
+	idx := len(g.defs)
+	g.defs = append(g.defs, "")
+
+	fieldNames := map[string]bool{}
+	for _, jsonName := range s.fields {
+		field := s.byName[jsonName]
+		goName := exportedName(jsonName)
+		for fieldNames[goName] {
+			goName += "_"
+		}
+		fieldNames[goName] = true
+
+		fmt.Fprintf(&b, "\t%s %s `json:\"%s,omitempty\"`\n", goName, g.goType(name+goName, field), jsonName)
+	}
+	b.WriteString("}\n")
+
+	g.defs[idx] = b.String()
+	return name
+}
+
+// goType returns the Go type for a shape; nested objects become own struct types named by nameHint
+func (g *generator) goType(nameHint string, s *shape) string {
+	var typ string
+	pointer := s.nullable()
+
+	switch s.kinds {
+	case 0:
+		return "interface{}"
+	case kindString, kindString | kindDate, kindString | kindDateTime, kindString | kindDate | kindDateTime:
+		typ = "string"
+	case kindDateTime, kindDateTime | kindDate:
+		g.imports[importPxtime] = true
+		typ, pointer = "pxtime.DateTime", true
+	case kindDate:
+		g.imports[importPxtime] = true
+		typ, pointer = "pxtime.Date", true
+	// Numbers and booleans are pointers: with omitempty 0 and false would never be sent
+	case kindInt:
+		typ, pointer = "int", true
+	case kindFloat, kindInt | kindFloat:
+		// Amounts and quantities are decoded exactly
+		g.imports[importDecimal] = true
+		typ, pointer = "decimal.Decimal", true
+	case kindBool:
+		typ, pointer = "bool", true
+	case kindObject:
+		// Nested objects are pointers like the references in package models
+		typ, pointer = g.structType(nameHint, s.object), true
+	case kindArray:
+		if s.elem == nil || (s.elem.kinds == 0) {
+			return "[]interface{}"
+		}
+		elem := *s.elem
+		// Array elements are never pointers
+		elem.null, elem.missing = false, false
+		return "[]" + strings.TrimPrefix(g.goType(nameHint, &elem), "*")
+	default:
+		return "interface{}"
+	}
+
+	if pointer {
+		return "*" + typ
+	}
+	return typ
+}
+
+// uniqueName returns name or a numbered variant not used yet
+func (g *generator) uniqueName(name string) string {
+	unique := name
+	for i := 2; g.used[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.used[unique] = true
+	return unique
+}
+
+// exportedName converts a JSON field name (e.g. Z_ExternalID) to an exported Go identifier (ZExternalID)
+func exportedName(jsonName string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range jsonName {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "F" + name
+	}
+	return name
+}
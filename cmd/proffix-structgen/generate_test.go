@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var adressenSample = `[{
+	"AdressNr": 1,
+	"Name": "Muster AG",
+	"Vorname": null,
+	"Umsatz": 1200,
+	"Aktiv": true,
+	"ErstelltAm": "2024-03-01 08:15:30",
+	"Geburtsdatum": "1980-05-17",
+	"Land": {"LandNr": "CH"},
+	"Kontakte": [{"KontaktNr": 5, "Name": "Meier"}],
+	"Z_ExternalID": "SHOP-1"
+}, {
+	"AdressNr": 2,
+	"Name": "Beispiel GmbH",
+	"Vorname": "Hans",
+	"Umsatz": 99.5,
+	"Aktiv": false,
+	"ErstelltAm": "2024-03-02 10:00:00",
+	"Geburtsdatum": "",
+	"Land": {"LandNr": "DE"},
+	"Kontakte": []
+}]`
+
+func TestGenerate(t *testing.T) {
+	root, count, err := parseSamples([]byte(adressenSample))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 samples, got %v", count)
+	}
+
+	code, err := generate(genConfig{Package: "models", TypeName: "Adresse", Endpoint: "ADR/Adresse", Source: "test"}, root)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	src := strings.Join(strings.Fields(string(code)), " ")
+
+	for _, want := range []string{
+		"package models",
+		"AdressNr     *int               `json:\"AdressNr,omitempty\"`",
+		"Vorname      *string            `json:\"Vorname,omitempty\"`",
+		"Umsatz       *decimal.Decimal   `json:\"Umsatz,omitempty\"`",
+		"Aktiv        *bool              `json:\"Aktiv,omitempty\"`",
+		"ErstelltAm   *pxtime.DateTime   `json:\"ErstelltAm,omitempty\"`",
+		"Geburtsdatum *pxtime.Date       `json:\"Geburtsdatum,omitempty\"`",
+		"Land         *AdresseLand       `json:\"Land,omitempty\"`",
+		"Kontakte     []AdresseKontakte  `json:\"Kontakte,omitempty\"`",
+		"ZExternalID  *string            `json:\"Z_ExternalID,omitempty\"`",
+		"type AdresseLand struct",
+		"func NewAdresseResource(c *proffixrest.Client) *proffixrest.Resource[Adresse]",
+		`return proffixrest.NewResource[Adresse](c, "ADR/Adresse")`,
+	} {
+		if !strings.Contains(src, strings.Join(strings.Fields(want), " ")) {
+			t.Errorf("Expected generated code to contain %q:\n%s", want, src)
+		}
+	}
+}
+
+// TestGenerate_Golden compares the generated code with testdata/artikel.golden. Zero numbers and false
+// must become pointers, otherwise omitempty would drop them when writing.
+func TestGenerate_Golden(t *testing.T) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "artikel.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _, err := parseSamples(sample)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	code, err := generate(genConfig{Package: "models", TypeName: "Artikel", Source: "artikel.json"}, root)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	golden := filepath.Join("testdata", "artikel.golden")
+	if *update {
+		if err := os.WriteFile(golden, code, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := lineDiff("artikel.golden", "generated", string(want), string(code)); d != "" {
+		t.Errorf("Expected generated code to match the golden file:\n%s", d)
+	}
+}
+
+func TestParseSamples_Invalid(t *testing.T) {
+	if _, _, err := parseSamples([]byte(`[1,2,3]`)); err == nil {
+		t.Errorf("Expected error for array of numbers")
+	}
+	if _, _, err := parseSamples([]byte(`{"a":`)); err == nil {
+		t.Errorf("Expected error for truncated JSON")
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	cases := map[string]string{
+		"AdressNr":     "AdressNr",
+		"Z_ExternalID": "ZExternalID",
+		"z_feld":       "ZFeld",
+		"1Feld":        "F1Feld",
+		"Währung":      "Währung",
+	}
+	for in, want := range cases {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	if d := lineDiff("a", "b", "x\ny\n", "x\ny\n"); d != "" {
+		t.Errorf("Expected no diff, got %q", d)
+	}
+
+	d := lineDiff("old", "new", "a\nb\nc\n", "a\nB\nc\nd\n")
+	for _, want := range []string{"--- old", "+++ new", "-b", "+B", "+d", " a"} {
+		if !strings.Contains(d, want) {
+			t.Errorf("Expected diff to contain %q:\n%s", want, d)
+		}
+	}
+}
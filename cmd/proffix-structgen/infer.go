@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Patterns of the PROFFIX REST-API timestamps and dates
+var (
+	reDateTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`)
+	reDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// kind is the inferred JSON kind of a value
+type kind int
+
+const (
+	kindString kind = 1 << iota
+	kindDateTime
+	kindDate
+	kindInt
+	kindFloat
+	kindBool
+	kindObject
+	kindArray
+)
+
+// shape collects everything seen for one JSON value across all samples
+type shape struct {
+	kinds   kind
+	null    bool
+	missing bool   // field was absent in at least one sample
+	object  *shape // merged shape of nested objects
+	fields  []string
+	byName  map[string]*shape
+	elem    *shape // merged shape of array elements
+	samples int    // number of objects merged into this shape
+}
+
+func newShape() *shape {
+	return &shape{byName: map[string]*shape{}}
+}
+
+// parseSamples reads a JSON array of objects or a single object into a merged shape
+func parseSamples(data []byte) (*shape, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	root := newShape()
+	if err := root.merge(dec); err != nil {
+		return nil, 0, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, 0, fmt.Errorf("unexpected data after JSON value")
+	}
+
+	switch {
+	case root.kinds == kindObject:
+		return root.object, 1, nil
+	case root.kinds == kindArray && root.elem != nil && root.elem.kinds == kindObject:
+		return root.elem.object, root.elem.object.samples, nil
+	default:
+		return nil, 0, fmt.Errorf("expected a JSON object or an array of objects")
+	}
+}
+
+// merge reads the next JSON value from dec and merges it into s
+func (s *shape) merge(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+	case nil:
+		s.null = true
+	case bool:
+		s.kinds |= kindBool
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			s.kinds |= kindFloat
+		} else {
+			s.kinds |= kindInt
+		}
+	case string:
+		switch {
+		case v == "":
+			// Empty strings are used by PROFFIX like null
+			s.null = true
+		case reDateTime.MatchString(v):
+			s.kinds |= kindDateTime
+		case reDate.MatchString(v):
+			s.kinds |= kindDate
+		default:
+			s.kinds |= kindString
+		}
+	case json.Delim:
+		if v == '{' {
+			s.kinds |= kindObject
+			if s.object == nil {
+				s.object = newShape()
+			}
+			return s.object.mergeObject(dec)
+		}
+		s.kinds |= kindArray
+		if s.elem == nil {
+			s.elem = newShape()
+		}
+		for dec.More() {
+			if err := s.elem.merge(dec); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+	return nil
+}
+
+// mergeObject merges the members of an object whose '{' was already read
+func (s *shape) mergeObject(dec *json.Decoder) error {
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		field, ok := s.byName[name]
+		if !ok {
+			field = newShape()
+			// Fields appearing later were missing in all previous samples
+			field.missing = s.samples > 0
+			s.byName[name] = field
+			s.fields = append(s.fields, name)
+		}
+		seen[name] = true
+		if err := field.merge(dec); err != nil {
+			return err
+		}
+	}
+	for name, field := range s.byName {
+		if !seen[name] {
+			field.missing = true
+		}
+	}
+	s.samples++
+	_, err := dec.Token()
+	return err
+}
+
+// nullable reports whether the value was null, empty or missing in any sample
+func (s *shape) nullable() bool {
+	return s.null || s.missing
+}
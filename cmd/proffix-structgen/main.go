@@ -0,0 +1,145 @@
+// Package main provides proffix-structgen, a generator for Go structs from PROFFIX REST-API responses.
+//
+// The tool samples an endpoint through GetBatch (or reads a saved JSON response), infers field types,
+// nullability and nested objects and writes Go structs with JSON tags plus a Resource binding.
+// Custom fields (Z_ fields) of a customer are picked up automatically.
+//
+// Examples:
+//
+//	proffix-structgen -endpoint ADR/Adresse -type Adresse -out adresse_gen.go
+//	proffix-structgen -input adressen.json -endpoint ADR/Adresse -type Adresse -out adresse_gen.go
+//	proffix-structgen -endpoint ADR/Adresse -type Adresse -out adresse_gen.go -diff
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest"
+)
+
+func main() {
+	// Connection (Params, same as proffix-rest)
+	resturl := flag.String("url", os.Getenv("PXR_URL"), "URL of PROFFIX REST-API in Format <https://>URL:Port")
+	user := flag.String("user", os.Getenv("PXR_USER"), "User of PROFFIX REST-API")
+	password := flag.String("password", os.Getenv("PXR_PASSWORD"), "Password of User. Either as SHA256 - Hash or plain text")
+	database := flag.String("database", os.Getenv("PXR_DATABASE"), "Database in PROFFIX")
+	module := flag.String("module", os.Getenv("PXR_MODULE"), "Module which are needed for Licence")
+
+	// Source
+	endpoint := flag.String("endpoint", "", "Endpoint to sample and to bind the Resource to, e.g. ADR/Adresse")
+	input := flag.String("input", "", "Read samples from a saved JSON response instead of the API")
+	filter := flag.String("filter", "", "Filter for sampling the endpoint")
+	batchsize := flag.Int("batchsize", 0, "Batchsize for sampling the endpoint")
+
+	// Output
+	typeName := flag.String("type", "", "Name of the generated struct (required)")
+	pkg := flag.String("package", "models", "Package of the generated file")
+	out := flag.String("out", "", "Output file; prints to stdout if empty")
+	diff := flag.Bool("diff", false, "Only show the diff against the existing output file; exits with 1 if it changed")
+
+	flag.Parse()
+
+	if *typeName == "" || (*endpoint == "" && *input == "") {
+		fmt.Fprintln(os.Stderr, "proffix-structgen: -type and -endpoint or -input are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		data   []byte
+		source string
+		err    error
+	)
+
+	// The endpoint is preferred as source so regenerating from a file or the API gives the same header
+	source = *endpoint
+	if *input != "" {
+		if source == "" {
+			source = filepath.Base(*input)
+		}
+		data, err = os.ReadFile(*input)
+	} else {
+		data, err = sample(*resturl, *user, *password, *database, *module, *endpoint, *filter, *batchsize)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	root, count, err := parseSamples(data)
+	if err != nil {
+		fail(err)
+	}
+
+	code, err := generate(genConfig{Package: *pkg, TypeName: *typeName, Endpoint: *endpoint, Source: source}, root)
+	if err != nil {
+		fail(err)
+	}
+
+	if *out == "" {
+		fmt.Print(string(code))
+		return
+	}
+
+	existing, err := os.ReadFile(*out)
+	if err != nil && !os.IsNotExist(err) {
+		fail(err)
+	}
+	changes := lineDiff(*out, *out+" (generated)", string(existing), string(code))
+
+	if *diff {
+		if changes != "" {
+			fmt.Print(changes)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%s is up to date (%d samples)\n", *out, count)
+		return
+	}
+
+	if changes == "" {
+		fmt.Fprintf(os.Stderr, "%s is up to date (%d samples)\n", *out, count)
+		return
+	}
+	if len(existing) > 0 {
+		fmt.Print(changes)
+	}
+	if err := os.WriteFile(*out, code, 0o600); err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%d samples)\n", *out, count)
+}
+
+// sample fetches all entries of an endpoint through GetBatch
+func sample(resturl, user, password, database, module, endpoint, filter string, batchsize int) ([]byte, error) {
+	pxrest, err := proffixrest.NewClient(resturl, user, password, database, strings.Split(module, ","), &proffixrest.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	defer func() { _, _ = pxrest.Logout(ctx) }()
+
+	params := url.Values{}
+	if filter != "" {
+		params.Set("Filter", filter)
+	}
+
+	data, _, err := pxrest.GetBatch(ctx, endpoint, params, batchsize)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no entries found on %s", endpoint)
+	}
+	return data, nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "proffix-structgen: %v\n", err)
+	os.Exit(1)
+}
@@ -2,4 +2,4 @@ module github.com/pitwch/go-wrapper-proffix-restapi
 
 require github.com/xiaost/jsonport v0.0.0-20180416162420-304b563aed59
 
-go 1.13
+go 1.20
@@ -0,0 +1,89 @@
+package proffixrest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/models"
+)
+
+// attachCleanupTimeout limits the removal of an uploaded file after a failed link
+const attachCleanupTimeout = 30 * time.Second
+
+// Attach uploads the data of r as filename to PRO/Datei and links it to the entry key of endpoint
+// by creating a document at <endpoint>/<key>/Dokument (e.g. ADR/Adresse/1/Dokument).
+// metadata is sent with the link; DateiNr is set to the uploaded file and Bezeichnung defaults to filename.
+// If the link cannot be created, the uploaded file is deleted again.
+// Once the link is created Attach succeeds; if reading the created document fails, only DokumentNr and DateiNr
+// are set and the failure is logged.
+func (c *Client) Attach(ctx context.Context, endpoint string, key interface{}, filename string, r io.Reader, metadata map[string]interface{}) (*models.Anhang, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, toPxError(err, "PRO/Datei")
+	}
+
+	rc, header, status, err := c.File(ctx, filename, data)
+	closeBody(rc)
+	if err != nil {
+		return nil, err
+	}
+	dateiNr := ConvertLocationToID(header)
+	if header.Get("Location") == "" || dateiNr == "" {
+		return nil, &PxError{Endpoint: "PRO/Datei", Status: status, Message: "Upload returned no DateiNr"}
+	}
+
+	body := make(map[string]interface{}, len(metadata)+2)
+	for k, v := range metadata {
+		body[k] = v
+	}
+	body["DateiNr"] = dateiNr
+	if _, ok := body["Bezeichnung"]; !ok {
+		body["Bezeichnung"] = filename
+	}
+
+	linkEndpoint := endpoint + "/" + url.PathEscape(fmt.Sprintf("%v", key)) + "/Dokument"
+	rc, header, _, err = c.Post(ctx, linkEndpoint, body)
+	closeBody(rc)
+	if err != nil {
+		c.removeUpload(ctx, dateiNr)
+		return nil, err
+	}
+
+	anhang := &models.Anhang{DateiNr: dateiNr}
+	id := ConvertLocationToID(header)
+	dokumentNr, err := strconv.Atoi(id)
+	if err != nil {
+		logDebug(ctx, c, fmt.Sprintf("Linked file %s to %s, but got no valid DokumentNr: %q", dateiNr, linkEndpoint, id))
+		return anhang, nil
+	}
+	anhang.DokumentNr = dokumentNr
+
+	// The link exists; reading it back only completes the record
+	rc, _, _, err = c.Get(ctx, linkEndpoint+"/"+id, nil)
+	if err != nil {
+		logDebug(ctx, c, fmt.Sprintf("Could not read linked document %s/%s: %v", linkEndpoint, id, err))
+		return anhang, nil
+	}
+	linked := *anhang
+	if err := decodeBody(rc, &linked); err != nil {
+		logDebug(ctx, c, fmt.Sprintf("Could not read linked document %s/%s: %v", linkEndpoint, id, err))
+		return anhang, nil
+	}
+	return &linked, nil
+}
+
+// removeUpload deletes an uploaded file, also if ctx is already cancelled
+func (c *Client) removeUpload(ctx context.Context, dateiNr string) {
+	cleanupCtx, cancel := context.WithTimeout(detachedContext{ctx}, attachCleanupTimeout)
+	defer cancel()
+
+	rc, _, _, err := c.Delete(cleanupCtx, "PRO/Datei/"+dateiNr)
+	closeBody(rc)
+	if err != nil {
+		logDebug(ctx, c, fmt.Sprintf("Could not delete uploaded file %s: %v", dateiNr, err))
+	}
+}
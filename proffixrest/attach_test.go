@@ -0,0 +1,130 @@
+package proffixrest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// attachFake serves uploads to PRO/Datei and the documents of ADR/Adresse/1
+type attachFake struct {
+	*fakePX
+
+	mu       sync.Mutex
+	uploads  map[string]string // DateiNr -> content
+	links    []map[string]interface{}
+	failLink bool
+	failRead bool   // Reading the created document fails
+	location string // Location of the created document; default is ADR/Adresse/1/Dokument/1
+}
+
+func newAttachFake(t *testing.T) *attachFake {
+	t.Helper()
+	f := &attachFake{fakePX: newFakePX(t, nil), uploads: map[string]string{}}
+	f.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && endpoint == "PRO/Datei":
+			data, _ := io.ReadAll(r.Body)
+			f.uploads["tmp-1"] = string(data)
+			w.Header().Set("Location", f.server.URL+"/pxapi/v4/PRO/Datei/tmp-1")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && strings.HasPrefix(endpoint, "PRO/Datei/"):
+			delete(f.uploads, strings.TrimPrefix(endpoint, "PRO/Datei/"))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && endpoint == "ADR/Adresse/1/Dokument":
+			if f.failLink {
+				writePxError(w, http.StatusBadRequest, "VALIDATION", "Dokumentkategorie fehlt")
+				return true
+			}
+			link := decodeRecord(r.Body)
+			link["DokumentNr"] = len(f.links) + 1
+			f.links = append(f.links, link)
+			location := f.location
+			if location == "" {
+				location = "ADR/Adresse/1/Dokument/1"
+			}
+			w.Header().Set("Location", f.server.URL+"/pxapi/v4/"+location)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && endpoint == "ADR/Adresse/1/Dokument/1":
+			if f.failRead {
+				writePxError(w, http.StatusInternalServerError, "ERROR", "Interner Fehler")
+				return true
+			}
+			writeJSON(w, f.links[0])
+		default:
+			return false
+		}
+		return true
+	}
+	return f
+}
+
+func TestClient_Attach(t *testing.T) {
+	f := newAttachFake(t)
+	c := f.client(nil)
+
+	anhang, err := c.Attach(context.Background(), "ADR/Adresse", 1, "Vertrag.pdf", strings.NewReader("%PDF"), map[string]interface{}{"Bemerkungen": "Unterschrieben"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if anhang.DokumentNr != 1 || anhang.DateiNr != "tmp-1" || anhang.Bezeichnung != "Vertrag.pdf" {
+		t.Errorf("Expected linked document 1 with DateiNr tmp-1, got %+v", anhang)
+	}
+	if anhang.Bemerkungen == nil || *anhang.Bemerkungen != "Unterschrieben" {
+		t.Errorf("Expected metadata to be sent, got %v", anhang.Bemerkungen)
+	}
+	if f.uploads["tmp-1"] != "%PDF" {
+		t.Errorf("Expected uploaded content, got %q", f.uploads["tmp-1"])
+	}
+}
+
+func TestClient_Attach_CleansUpOnLinkError(t *testing.T) {
+	f := newAttachFake(t)
+	f.failLink = true
+	c := f.client(nil)
+
+	anhang, err := c.Attach(context.Background(), "ADR/Adresse", 1, "Vertrag.pdf", strings.NewReader("%PDF"), nil)
+	if err == nil || anhang != nil {
+		t.Fatalf("Expected error without record, got %+v", anhang)
+	}
+	if pxErr, ok := err.(*PxError); !ok || pxErr.Status != http.StatusBadRequest {
+		t.Errorf("Expected the error of the link request, got %v", err)
+	}
+	if n := f.callCount("DELETE", "PRO/Datei/tmp-1"); n != 1 {
+		t.Errorf("Expected uploaded file to be deleted, got %d DELETE requests", n)
+	}
+	if len(f.uploads) != 0 {
+		t.Errorf("Expected no uploads left, got %v", f.uploads)
+	}
+}
+
+func TestClient_Attach_ReadbackFailureIsNotFatal(t *testing.T) {
+	f := newAttachFake(t)
+	f.failRead = true
+	c := f.client(nil)
+
+	anhang, err := c.Attach(context.Background(), "ADR/Adresse", 1, "Vertrag.pdf", strings.NewReader("%PDF"), nil)
+	if err != nil {
+		t.Fatalf("Expected no error once the link exists, got %v", err)
+	}
+	if anhang.DokumentNr != 1 || anhang.DateiNr != "tmp-1" {
+		t.Errorf("Expected DokumentNr 1 and DateiNr tmp-1, got %+v", anhang)
+	}
+	if n := f.callCount("DELETE", "PRO/Datei"); n != 0 {
+		t.Errorf("Expected the linked file to be kept, got %d DELETE requests", n)
+	}
+
+	// Without a numeric DokumentNr the link is still reported as created
+	f.mu.Lock()
+	f.failRead, f.location = false, "ADR/Adresse/1/Dokument/neu"
+	f.mu.Unlock()
+	anhang, err = c.Attach(context.Background(), "ADR/Adresse", 1, "Vertrag.pdf", strings.NewReader("%PDF"), nil)
+	if err != nil || anhang.DokumentNr != 0 || anhang.DateiNr != "tmp-1" {
+		t.Errorf("Expected linked file without DokumentNr, got %+v (%v)", anhang, err)
+	}
+}
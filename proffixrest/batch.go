@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/url"
 	"strconv"
+	"strings"
 )
 
 // GetBatch automatically paginates all possible queries
@@ -152,3 +154,83 @@ func (c *Client) GetBatch(ctx context.Context, endpoint string, params url.Value
 	// If count of elements in first query is bigger or same than FilteredCount return
 	return collector, totalEntriesCount, err
 }
+
+// getAll reads all entries of endpoint page by page, sorted by keyField. Every page continues after the last
+// key (keyset paging), so entries deleted during the read do not shift the pages and skip others.
+// Unlike GetBatch it fails if a page fails, PROFFIX sends no FilteredCount or fewer entries arrive than counted,
+// so a partial read is never taken for a complete one.
+func (c *Client) getAll(ctx context.Context, endpoint string, keyField string, params url.Values, batchsize int) ([]map[string]interface{}, error) {
+	if batchsize == 0 {
+		batchsize = c.option.Batchsize
+	}
+	filter := ""
+	if params != nil {
+		filter = params.Get("Filter")
+	}
+
+	paramquery := url.Values{}
+	for key, val := range params {
+		paramquery[key] = append([]string{}, val...)
+	}
+	paramquery.Set("Sort", keyField)
+
+	var records []map[string]interface{}
+	for {
+		page, total, err := c.getPage(ctx, endpoint, paramquery, batchsize, 0)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+
+		// total counts the entries after the last key, including this page
+		if len(page) >= total {
+			return records, nil
+		}
+		if len(page) == 0 {
+			return nil, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("Incomplete read: %d entries, %d more counted", len(records), total)}
+		}
+
+		last := itemKey(page[len(page)-1], keyField)
+		if last == "" {
+			return nil, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("Entry without %s, cannot continue reading", keyField)}
+		}
+		clause := fmt.Sprintf("%s>'%s'", keyField, strings.ReplaceAll(last, "'", "''"))
+		if filter != "" {
+			clause = filter + "," + clause
+		}
+		paramquery.Set("Filter", clause)
+	}
+}
+
+// getPage reads limit entries of endpoint starting at offset and returns them with the FilteredCount
+func (c *Client) getPage(ctx context.Context, endpoint string, params url.Values, limit int, offset int) ([]map[string]interface{}, int, error) {
+	paramquery := url.Values{}
+	for key, val := range params {
+		paramquery[key] = append([]string{}, val...)
+	}
+	paramquery.Set("Limit", strconv.Itoa(limit))
+	paramquery.Set("Offset", strconv.Itoa(offset))
+
+	rc, header, _, err := c.Get(ctx, endpoint, paramquery)
+	if err != nil {
+		closeBody(rc)
+		return nil, 0, err
+	}
+	var data []byte
+	if rc != nil {
+		data, err = io.ReadAll(rc)
+		closeBody(rc)
+	}
+	if err != nil {
+		return nil, 0, toPxError(err, endpoint)
+	}
+
+	var page []map[string]interface{}
+	if err := unmarshalUseNumber(data, &page); err != nil {
+		return nil, 0, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+	}
+	if header.Get("pxmetadata") == "" {
+		return nil, 0, &PxError{Endpoint: endpoint, Message: "PROFFIX sent no FilteredCount (pxmetadata header)"}
+	}
+	return page, GetFilteredCount(header), nil
+}
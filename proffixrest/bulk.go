@@ -0,0 +1,118 @@
+package proffixrest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// BulkOptions configures DeleteWhere and PatchWhere
+type BulkOptions struct {
+	MaxCount    int  // Aborts without changes if more entries match. 0 means no limit
+	DryRun      bool // Only list the matching keys
+	Concurrency int  // Number of requests in parallel. Default is 1
+}
+
+// BulkItemResult is the result for a single entry of a bulk operation
+type BulkItemResult struct {
+	Key    string   `json:"Key"`
+	Status int      `json:"Status"`          // HTTP status (0 in dry run)
+	Error  *PxError `json:"Error,omitempty"` // Error if the request failed
+}
+
+// BulkResult is the result of DeleteWhere and PatchWhere in the order of the matching keys
+type BulkResult struct {
+	Total     int              `json:"Total"` // Number of matching entries
+	Succeeded int              `json:"Succeeded"`
+	Failed    int              `json:"Failed"`
+	DryRun    bool             `json:"DryRun"`
+	Items     []BulkItemResult `json:"Items"`
+}
+
+// DeleteWhere deletes all entries of endpoint matching query (e.g. Filter) concurrently.
+// keyField is the key of the endpoint used to build the URL of each entry.
+func (c *Client) DeleteWhere(ctx context.Context, endpoint string, keyField string, query url.Values, opts *BulkOptions) (*BulkResult, error) {
+	return c.bulkWhere(ctx, endpoint, keyField, query, opts, func(ctx context.Context, keyEndpoint string) (int, error) {
+		rc, _, status, err := c.Delete(ctx, keyEndpoint)
+		closeBody(rc)
+		return status, err
+	})
+}
+
+// PatchWhere sends fields as PATCH to all entries of endpoint matching query (e.g. Filter) concurrently.
+// keyField is the key of the endpoint used to build the URL of each entry.
+func (c *Client) PatchWhere(ctx context.Context, endpoint string, keyField string, query url.Values, fields map[string]interface{}, opts *BulkOptions) (*BulkResult, error) {
+	if len(fields) == 0 {
+		return nil, &PxError{Endpoint: endpoint, Message: "PatchWhere needs at least one field"}
+	}
+	return c.bulkWhere(ctx, endpoint, keyField, query, opts, func(ctx context.Context, keyEndpoint string) (int, error) {
+		rc, _, status, err := c.Patch(ctx, keyEndpoint, fields)
+		closeBody(rc)
+		return status, err
+	})
+}
+
+// bulkWhere enumerates the matching keys and runs op for each of them
+func (c *Client) bulkWhere(ctx context.Context, endpoint string, keyField string, query url.Values, opts *BulkOptions, op func(ctx context.Context, keyEndpoint string) (int, error)) (*BulkResult, error) {
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+
+	keys, err := c.enumerateKeys(ctx, endpoint, keyField, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxCount > 0 && len(keys) > opts.MaxCount {
+		return nil, &PxError{Endpoint: endpoint, Type: "MAX_COUNT_EXCEEDED", Message: fmt.Sprintf("%d entries match, maximum is %d", len(keys), opts.MaxCount)}
+	}
+
+	res := &BulkResult{Total: len(keys), DryRun: opts.DryRun, Items: make([]BulkItemResult, len(keys))}
+	if opts.DryRun {
+		for i, key := range keys {
+			res.Items[i] = BulkItemResult{Key: key}
+		}
+		return res, nil
+	}
+
+	parallelN(opts.Concurrency, len(keys), func(i int) {
+		keyEndpoint := endpoint + "/" + url.PathEscape(keys[i])
+		status, err := op(ctx, keyEndpoint)
+		res.Items[i] = BulkItemResult{Key: keys[i], Status: status}
+		if err != nil {
+			res.Items[i].Error = toPxError(err, keyEndpoint)
+		}
+	})
+
+	for _, item := range res.Items {
+		if item.Error != nil {
+			res.Failed++
+		} else {
+			res.Succeeded++
+		}
+	}
+	return res, nil
+}
+
+// enumerateKeys returns the keys of all entries of endpoint matching query; an incomplete read is an error
+func (c *Client) enumerateKeys(ctx context.Context, endpoint string, keyField string, query url.Values) ([]string, error) {
+	params := url.Values{}
+	for k, v := range query {
+		params[k] = append([]string(nil), v...)
+	}
+	params.Set("Fields", keyField)
+
+	// Fails on a partial read, so no operation runs on only a part of the matching entries
+	records, err := c.getAll(WithoutCache(ctx), endpoint, keyField, params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(records))
+	for _, record := range records {
+		if key := itemKey(record, keyField); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
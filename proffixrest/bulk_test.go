@@ -0,0 +1,125 @@
+package proffixrest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// bulkFake returns a fake with 20 articles; every second one belongs to group TEST
+func bulkFake(t *testing.T) *fakePX {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	for i := 0; i < 20; i++ {
+		gruppe := "SHOP"
+		if i%2 == 0 {
+			gruppe = "TEST"
+		}
+		px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": fmt.Sprintf("A%02d", i), "Gruppe": gruppe, "Aktiv": true})
+	}
+	return px
+}
+
+func testQuery() url.Values {
+	query := url.Values{}
+	query.Set("Filter", "Gruppe=='TEST'")
+	return query
+}
+
+func TestClient_DeleteWhere(t *testing.T) {
+	px := bulkFake(t)
+
+	res, err := px.client(&Options{Batchsize: 3}).DeleteWhere(context.Background(), "LAG/Artikel", "ArtikelNr", testQuery(), &BulkOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Total != 10 || res.Succeeded != 10 || res.Failed != 0 {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+	if res.Items[0].Key != "A00" || res.Items[9].Key != "A18" || res.Items[0].Status != 204 {
+		t.Errorf("Expected items in key order, got %+v", res.Items)
+	}
+	if px.count("LAG/Artikel") != 10 {
+		t.Errorf("Expected 10 articles left, got %v", px.count("LAG/Artikel"))
+	}
+}
+
+func TestClient_DeleteWhere_IncompleteRead(t *testing.T) {
+	px := bulkFake(t)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodGet && endpoint == "LAG/Artikel" && strings.Contains(r.URL.Query().Get("Filter"), "ArtikelNr>") {
+			writePxError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "Server busy")
+			return true
+		}
+		return false
+	}
+
+	res, err := px.client(&Options{Batchsize: 3}).DeleteWhere(context.Background(), "LAG/Artikel", "ArtikelNr", testQuery(), nil)
+	if err == nil || res != nil {
+		t.Errorf("Expected error for a failed page, got %+v", res)
+	}
+	if n := px.callCount("DELETE", "LAG/Artikel"); n != 0 || px.count("LAG/Artikel") != 20 {
+		t.Errorf("Expected nothing to be deleted, got %d DELETE requests", n)
+	}
+}
+
+func TestClient_DeleteWhere_DryRunAndMaxCount(t *testing.T) {
+	px := bulkFake(t)
+	c := px.client(nil)
+
+	res, err := c.DeleteWhere(context.Background(), "LAG/Artikel", "ArtikelNr", testQuery(), &BulkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !res.DryRun || res.Total != 10 || len(res.Items) != 10 || res.Succeeded != 0 {
+		t.Errorf("Unexpected dry run result: %+v", res)
+	}
+
+	_, err = c.DeleteWhere(context.Background(), "LAG/Artikel", "ArtikelNr", testQuery(), &BulkOptions{MaxCount: 5})
+	if pxErr, ok := err.(*PxError); !ok || pxErr.Type != "MAX_COUNT_EXCEEDED" {
+		t.Errorf("Expected MAX_COUNT_EXCEEDED, got %v", err)
+	}
+
+	if px.callCount("DELETE", "LAG/Artikel") != 0 || px.count("LAG/Artikel") != 20 {
+		t.Errorf("Expected nothing to be deleted")
+	}
+}
+
+func TestClient_PatchWhere(t *testing.T) {
+	px := bulkFake(t)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodPatch && endpoint == "LAG/Artikel/A04" {
+			writePxError(w, 409, "CONFLICT", "Artikel gesperrt")
+			return true
+		}
+		return false
+	}
+
+	res, err := px.client(nil).PatchWhere(context.Background(), "LAG/Artikel", "ArtikelNr", testQuery(), map[string]interface{}{"Aktiv": false}, &BulkOptions{Concurrency: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Succeeded != 9 || res.Failed != 1 {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+	if item := res.Items[2]; item.Key != "A04" || item.Status != 409 || item.Error == nil || item.Error.Type != "CONFLICT" {
+		t.Errorf("Expected typed error for A04, got %+v", item)
+	}
+
+	rec, _ := px.get("LAG/Artikel", "A02")
+	if rec["Aktiv"] != false {
+		t.Errorf("Expected A02 to be patched, got %v", rec)
+	}
+	rec, _ = px.get("LAG/Artikel", "A01")
+	if rec["Aktiv"] != true {
+		t.Errorf("Expected A01 outside the filter to be unchanged, got %v", rec)
+	}
+
+	if _, err := px.client(nil).PatchWhere(context.Background(), "LAG/Artikel", "ArtikelNr", testQuery(), nil, nil); err == nil {
+		t.Errorf("Expected error without fields")
+	}
+}
@@ -0,0 +1,479 @@
+package proffixrest
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEntry is a cached GET response
+type CacheEntry struct {
+	Key     string      `json:"Key"`
+	Status  int         `json:"Status"`
+	Header  http.Header `json:"Header"`
+	Body    []byte      `json:"Body"`
+	Expires time.Time   `json:"Expires"`
+}
+
+// size returns the approximate memory used by the entry
+func (e CacheEntry) size() int64 {
+	return int64(len(e.Key) + len(e.Body))
+}
+
+// CacheBackend stores cached responses. Implementations must be safe for concurrent use.
+type CacheBackend interface {
+	// Get returns the entry of key; expired entries may be returned and are removed by the client
+	Get(key string) (CacheEntry, bool)
+	// Set stores an entry and evicts other entries if the backend is full
+	Set(entry CacheEntry)
+	// Delete removes the entry of key
+	Delete(key string)
+	// DeletePrefix removes all entries whose key starts with prefix
+	DeletePrefix(prefix string)
+}
+
+// CacheRule enables caching for the endpoints starting with Prefix
+type CacheRule struct {
+	Prefix  string        // Endpoint prefix, e.g. "LAG/Einheit" or "ADR/Land"
+	TTL     time.Duration // Lifetime of an entry. Default is 5 minutes
+	MaxSize int64         // Responses with a larger body are not cached. 0 means no limit
+}
+
+// CacheOptions enables the read-through cache of Get.
+// Only endpoints matching a rule are cached; if several rules match the longest prefix wins.
+// Successful writes invalidate all entries of the same endpoint, e.g. a PATCH of ADR/Adresse/1 invalidates ADR/Adresse.
+type CacheOptions struct {
+	Backend CacheBackend // Default is NewMemoryCache(1000, 32 MB)
+	Rules   []CacheRule
+}
+
+// CacheStats counts the cache accesses of a client
+type CacheStats struct {
+	Hits          int64
+	Misses        int64
+	Stores        int64 // Responses written to the cache
+	Invalidations int64 // Invalidations by writes or InvalidateCache
+}
+
+// responseCache is the cache state of a client
+type responseCache struct {
+	backend       CacheBackend
+	rules         []CacheRule
+	hits          atomic.Int64
+	misses        atomic.Int64
+	stores        atomic.Int64
+	invalidations atomic.Int64
+}
+
+// newResponseCache builds the cache of a client; nil disables caching
+func newResponseCache(opts *CacheOptions) *responseCache {
+	if opts == nil {
+		return nil
+	}
+	cache := &responseCache{backend: opts.Backend, rules: append([]CacheRule(nil), opts.Rules...)}
+	if cache.backend == nil {
+		cache.backend = NewMemoryCache(1000, 32<<20)
+	}
+	for i := range cache.rules {
+		if cache.rules[i].TTL <= 0 {
+			cache.rules[i].TTL = 5 * time.Minute
+		}
+	}
+	// Longest prefix first
+	sort.SliceStable(cache.rules, func(i, j int) bool { return len(cache.rules[i].Prefix) > len(cache.rules[j].Prefix) })
+	return cache
+}
+
+// rule returns the rule of endpoint
+func (rc *responseCache) rule(endpoint string) (CacheRule, bool) {
+	for _, rule := range rc.rules {
+		if endpoint == rule.Prefix || strings.HasPrefix(endpoint, strings.TrimSuffix(rule.Prefix, "/")+"/") {
+			return rule, true
+		}
+	}
+	return CacheRule{}, false
+}
+
+// covers reports whether a rule caches endpoint or one of its sub-endpoints
+func (rc *responseCache) covers(endpoint string) bool {
+	if _, ok := rc.rule(endpoint); ok {
+		return true
+	}
+	base := strings.TrimSuffix(endpoint, "/") + "/"
+	for _, rule := range rc.rules {
+		if strings.HasPrefix(rule.Prefix, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// noCacheKey is the context key of WithoutCache
+type noCacheKey struct{}
+
+// WithoutCache returns a context whose Get requests bypass the cache, e.g. to read the current state of an entry.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// cacheKey returns the key of a GET request for the cache and deduplication; the params are sorted by name
+func (c *Client) cacheKey(endpoint string, params url.Values) string {
+	return c.Datenbank + "|" + endpoint + "?" + params.Encode()
+}
+
+// cachedGet returns a cached response or sends the request and caches its response
+func (c *Client) cachedGet(ctx context.Context, endpoint string, params url.Values, fetch func() (io.ReadCloser, http.Header, int, error)) (io.ReadCloser, http.Header, int, error) {
+	cache := c.cache
+	rule, ok := cache.rule(endpoint)
+	if !ok || ctx.Value(noCacheKey{}) != nil {
+		return fetch()
+	}
+
+	key := c.cacheKey(endpoint, params)
+	if entry, found := cache.backend.Get(key); found {
+		if time.Now().Before(entry.Expires) {
+			cache.hits.Add(1)
+			logDebug(ctx, c, "Cache hit: "+key)
+			return io.NopCloser(bytes.NewReader(entry.Body)), entry.Header.Clone(), entry.Status, nil
+		}
+		cache.backend.Delete(key)
+	}
+	cache.misses.Add(1)
+
+	body, header, status, err := fetch()
+	if err != nil || body == nil {
+		return body, header, status, err
+	}
+
+	// Read up to MaxSize; larger bodies are passed through without caching
+	limit := rule.MaxSize
+	var reader io.Reader = body
+	if limit > 0 {
+		reader = io.LimitReader(body, limit+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		closeBody(body)
+		return nil, header, status, &PxError{Endpoint: endpoint, Message: err.Error()}
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return readCloser{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}, header, status, nil
+	}
+	closeBody(body)
+
+	stored := header.Clone()
+	stored.Del("pxsessionid")
+	cache.backend.Set(CacheEntry{Key: key, Status: status, Header: stored, Body: data, Expires: time.Now().Add(rule.TTL)})
+	cache.stores.Add(1)
+	return io.NopCloser(bytes.NewReader(data)), header, status, nil
+}
+
+// readCloser combines a reader with the closer of the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// invalidateCache removes the cached responses of the endpoint written to.
+// The endpoint is reduced to its first two segments, e.g. ADR/Adresse/1/Dokument invalidates ADR/Adresse.
+func (c *Client) invalidateCache(endpoint string) {
+	if c.cache == nil {
+		return
+	}
+	segments := strings.SplitN(strings.Trim(endpoint, "/"), "/", 3)
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	base := strings.Join(segments, "/")
+	// Writes on endpoints without rule have nothing to invalidate
+	if !c.cache.covers(base) {
+		return
+	}
+	c.InvalidateCache(base)
+}
+
+// InvalidateCache removes all cached responses of endpoint and its sub-endpoints.
+func (c *Client) InvalidateCache(endpoint string) {
+	if c.cache == nil {
+		return
+	}
+	base := c.Datenbank + "|" + strings.TrimSuffix(endpoint, "/")
+	c.cache.backend.DeletePrefix(base + "?")
+	c.cache.backend.DeletePrefix(base + "/")
+	c.cache.invalidations.Add(1)
+}
+
+// CacheStats returns the cache statistics of the client. All values are 0 if the cache is disabled.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return CacheStats{
+		Hits:          c.cache.hits.Load(),
+		Misses:        c.cache.misses.Load(),
+		Stores:        c.cache.stores.Load(),
+		Invalidations: c.cache.invalidations.Load(),
+	}
+}
+
+// MemoryCache is an in-memory CacheBackend which evicts the least recently used entries
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	size       int64
+	order      *list.List // Front is the most recently used entry
+	entries    map[string]*list.Element
+}
+
+// NewMemoryCache creates a MemoryCache limited to maxEntries and maxBytes. 0 means no limit.
+func NewMemoryCache(maxEntries int, maxBytes int64) *MemoryCache {
+	return &MemoryCache{maxEntries: maxEntries, maxBytes: maxBytes, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the entry of key
+func (m *MemoryCache) Get(key string) (CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(CacheEntry), true
+}
+
+// Set stores an entry and evicts the least recently used entries if the cache is full
+func (m *MemoryCache) Set(entry CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.maxBytes > 0 && entry.size() > m.maxBytes {
+		return
+	}
+	if elem, ok := m.entries[entry.Key]; ok {
+		m.removeElement(elem)
+	}
+	m.entries[entry.Key] = m.order.PushFront(entry)
+	m.size += entry.size()
+
+	for (m.maxEntries > 0 && m.order.Len() > m.maxEntries) || (m.maxBytes > 0 && m.size > m.maxBytes) {
+		m.removeElement(m.order.Back())
+	}
+}
+
+// Delete removes the entry of key
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		m.removeElement(elem)
+	}
+}
+
+// DeletePrefix removes all entries whose key starts with prefix
+func (m *MemoryCache) DeletePrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, elem := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.removeElement(elem)
+		}
+	}
+}
+
+// Len returns the number of entries
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// removeElement removes an element of the LRU list
+func (m *MemoryCache) removeElement(elem *list.Element) {
+	entry := m.order.Remove(elem).(CacheEntry)
+	delete(m.entries, entry.Key)
+	m.size -= entry.size()
+}
+
+// DiskCache is a CacheBackend storing one file per entry in a directory, so the cache survives restarts.
+// If the directory exceeds maxBytes the least recently used files are removed.
+// Keys are indexed in memory, so a directory must only be used by one DiskCache at a time.
+type DiskCache struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	index    map[string]string // Key of every file by path; read from the directory on first use
+}
+
+// NewDiskCache creates a DiskCache in dir limited to maxBytes. 0 means no limit.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// Get returns the entry of key
+func (d *DiskCache) Get(key string) (CacheEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := d.path(key)
+	entry, err := readCacheFile(path)
+	if err != nil || entry.Key != key {
+		return CacheEntry{}, false
+	}
+	// The modification time is the last access for the LRU eviction
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return entry, true
+}
+
+// Set stores an entry and evicts the least recently used files if the directory is full
+func (d *DiskCache) Set(entry CacheEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil || (d.maxBytes > 0 && int64(len(data)) > d.maxBytes) {
+		return
+	}
+	d.loadIndex()
+	path := d.path(entry.Key)
+	if err := writeFileAtomic(path, data, 0o600); err != nil {
+		return
+	}
+	d.index[path] = entry.Key
+	d.evict()
+}
+
+// Delete removes the entry of key
+func (d *DiskCache) Delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.remove(d.path(key))
+}
+
+// DeletePrefix removes all entries whose key starts with prefix. Only the in-memory index is searched.
+func (d *DiskCache) DeletePrefix(prefix string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.loadIndex()
+	for path, key := range d.index {
+		if strings.HasPrefix(key, prefix) {
+			d.remove(path)
+		}
+	}
+}
+
+// loadIndex reads the keys of the files written before the start once; unreadable files are removed
+func (d *DiskCache) loadIndex() {
+	if d.index != nil {
+		return
+	}
+	d.index = map[string]string{}
+	files, err := d.files()
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		entry, err := readCacheFile(file.path)
+		if err != nil {
+			_ = os.Remove(file.path)
+			continue
+		}
+		d.index[file.path] = entry.Key
+	}
+}
+
+// remove deletes a file and its index entry
+func (d *DiskCache) remove(path string) {
+	_ = os.Remove(path)
+	if d.index != nil {
+		delete(d.index, path)
+	}
+}
+
+// cacheFile is a file of the DiskCache
+type cacheFile struct {
+	path     string
+	size     int64
+	accessed time.Time
+}
+
+// files lists the entry files of the cache
+func (d *DiskCache) files() ([]cacheFile, error) {
+	dirEntries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []cacheFile
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".json") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{path: filepath.Join(d.dir, dirEntry.Name()), size: info.Size(), accessed: info.ModTime()})
+	}
+	return files, nil
+}
+
+// evict removes the least recently used files until the cache fits into maxBytes
+func (d *DiskCache) evict() {
+	if d.maxBytes <= 0 {
+		return
+	}
+	files, err := d.files()
+	if err != nil {
+		return
+	}
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].accessed.Before(files[j].accessed) })
+	for _, file := range files {
+		if total <= d.maxBytes {
+			break
+		}
+		if os.Remove(file.path) == nil {
+			delete(d.index, file.path)
+			total -= file.size
+		}
+	}
+}
+
+// path returns the file of a key
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readCacheFile reads an entry file
+func readCacheFile(path string) (CacheEntry, error) {
+	var entry CacheEntry
+	data, err := os.ReadFile(path) // #nosec G304 -- file of the configured cache directory
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
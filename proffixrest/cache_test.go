@@ -0,0 +1,215 @@
+package proffixrest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getBody sends a GET request and returns the body
+func getBody(t *testing.T, c *Client, endpoint string, params url.Values) string {
+	t.Helper()
+	return getBodyCtx(t, context.Background(), c, endpoint, params)
+}
+
+// getBodyCtx sends a GET request with ctx and returns the body
+func getBodyCtx(t *testing.T, ctx context.Context, c *Client, endpoint string, params url.Values) string {
+	t.Helper()
+	rc, _, _, err := c.Get(ctx, endpoint, params)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := ReaderToByte(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return string(data)
+}
+
+func TestCache_HitsAndInvalidation(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Einheit": "EinheitNr", "ADR/Adresse": "AdressNr"})
+	px.put("LAG/Einheit", map[string]interface{}{"EinheitNr": "STK", "Bezeichnung": "Stück"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": "1"})
+	c := px.client(&Options{Cache: &CacheOptions{Rules: []CacheRule{{Prefix: "LAG/Einheit", TTL: time.Minute}}}})
+
+	first := getBody(t, c, "LAG/Einheit", url.Values{"Limit": {"10"}, "Fields": {"EinheitNr,Bezeichnung"}})
+	second := getBody(t, c, "LAG/Einheit", url.Values{"Fields": {"EinheitNr,Bezeichnung"}, "Limit": {"10"}})
+	if first != second || !strings.Contains(first, "Stück") {
+		t.Errorf("Expected identical cached response, got %s and %s", first, second)
+	}
+	if n := px.callCount("GET", "LAG/Einheit"); n != 1 {
+		t.Errorf("Expected 1 request for the same params in any order, got %d", n)
+	}
+
+	// Endpoints without rule are not cached
+	getBody(t, c, "ADR/Adresse", nil)
+	getBody(t, c, "ADR/Adresse", nil)
+	if n := px.callCount("GET", "ADR/Adresse"); n != 2 {
+		t.Errorf("Expected 2 requests for uncached endpoint, got %d", n)
+	}
+
+	// A write on an entry invalidates the list and the entries of the endpoint
+	getBody(t, c, "LAG/Einheit/STK", nil)
+	rc, _, _, err := c.Patch(context.Background(), "LAG/Einheit/STK", map[string]interface{}{"Bezeichnung": "Stk."})
+	closeBody(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if body := getBody(t, c, "LAG/Einheit/STK", nil); !strings.Contains(body, "Stk.") {
+		t.Errorf("Expected fresh entry after PATCH, got %s", body)
+	}
+	getBody(t, c, "LAG/Einheit", url.Values{"Limit": {"10"}, "Fields": {"EinheitNr,Bezeichnung"}})
+	if n := px.callCount("GET", "LAG/Einheit"); n != 4 {
+		t.Errorf("Expected list and entry to be fetched again, got %d requests", n)
+	}
+
+	stats := c.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Stores != 4 || stats.Invalidations != 1 {
+		t.Errorf("Expected 1 hit, 4 misses, 4 stores and 1 invalidation, got %+v", stats)
+	}
+}
+
+func TestCache_WriteWithoutRuleDoesNotInvalidate(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr", "ADR/Adresse": "AdressNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": "1"})
+	c := px.client(&Options{Cache: &CacheOptions{Rules: []CacheRule{{Prefix: "LAG/Einheit"}, {Prefix: "ADR/Adresse/1/Kontakt"}}}})
+
+	rc, _, _, err := c.Patch(context.Background(), "LAG/Artikel/A1", map[string]interface{}{"Bezeichnung": "Neu"})
+	closeBody(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats := c.CacheStats(); stats.Invalidations != 0 {
+		t.Errorf("Expected no invalidation for an endpoint without rule, got %+v", stats)
+	}
+
+	// A rule on a sub-endpoint is covered by writes on the endpoint
+	rc, _, _, err = c.Patch(context.Background(), "ADR/Adresse/1", map[string]interface{}{"Name": "Neu"})
+	closeBody(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats := c.CacheStats(); stats.Invalidations != 1 {
+		t.Errorf("Expected 1 invalidation, got %+v", stats)
+	}
+}
+
+func TestCache_TTLSizeAndBypass(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Land": "LandNr", "FIB/Konto": "KontoNr"})
+	px.put("ADR/Land", map[string]interface{}{"LandNr": "CH"})
+	px.put("FIB/Konto", map[string]interface{}{"KontoNr": "1000", "Bezeichnung": strings.Repeat("x", 200)})
+	c := px.client(&Options{Cache: &CacheOptions{Rules: []CacheRule{
+		{Prefix: "ADR", TTL: time.Hour},
+		{Prefix: "ADR/Land", TTL: 20 * time.Millisecond},
+		{Prefix: "FIB/Konto", MaxSize: 50},
+	}}})
+
+	// The longest prefix wins
+	getBody(t, c, "ADR/Land", nil)
+	getBody(t, c, "ADR/Land", nil)
+	time.Sleep(30 * time.Millisecond)
+	getBody(t, c, "ADR/Land", nil)
+	if n := px.callCount("GET", "ADR/Land"); n != 2 {
+		t.Errorf("Expected a new request after the TTL, got %d requests", n)
+	}
+
+	// Large responses are passed through completely but not cached
+	body := getBody(t, c, "FIB/Konto", nil)
+	if !strings.Contains(body, strings.Repeat("x", 200)) {
+		t.Errorf("Expected the complete body, got %d bytes", len(body))
+	}
+	getBody(t, c, "FIB/Konto", nil)
+	if n := px.callCount("GET", "FIB/Konto"); n != 2 {
+		t.Errorf("Expected large responses not to be cached, got %d requests", n)
+	}
+
+	getBodyCtx(t, WithoutCache(context.Background()), c, "ADR/Land", nil)
+	if n := px.callCount("GET", "ADR/Land"); n != 3 {
+		t.Errorf("Expected WithoutCache to bypass the cache, got %d requests", n)
+	}
+}
+
+func TestMemoryCache_LRU(t *testing.T) {
+	m := NewMemoryCache(2, 0)
+	m.Set(CacheEntry{Key: "a"})
+	m.Set(CacheEntry{Key: "b"})
+	m.Get("a")
+	m.Set(CacheEntry{Key: "c"})
+	if _, ok := m.Get("b"); ok {
+		t.Errorf("Expected least recently used entry b to be evicted")
+	}
+	if _, ok := m.Get("a"); !ok {
+		t.Errorf("Expected recently used entry a to be kept")
+	}
+
+	m = NewMemoryCache(0, 9)
+	m.Set(CacheEntry{Key: "a", Body: []byte("1234")})
+	m.Set(CacheEntry{Key: "b", Body: []byte("1234")})
+	if m.Len() != 1 {
+		t.Errorf("Expected byte limit to keep 1 entry, got %d", m.Len())
+	}
+	m.Set(CacheEntry{Key: "big", Body: []byte("12345678901")})
+	if _, ok := m.Get("big"); ok {
+		t.Errorf("Expected entry larger than the limit not to be stored")
+	}
+
+	m = NewMemoryCache(0, 0)
+	for _, key := range []string{"DB|ADR/Land?", "DB|ADR/Land/CH?", "DB|ADR/Landx?"} {
+		m.Set(CacheEntry{Key: key})
+	}
+	m.DeletePrefix("DB|ADR/Land?")
+	m.DeletePrefix("DB|ADR/Land/")
+	if m.Len() != 1 {
+		t.Errorf("Expected only the other endpoint to be kept, got %d entries", m.Len())
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	d, err := NewDiskCache(dir, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	d.Set(CacheEntry{Key: "DB|ADR/Land?", Status: 200, Body: []byte(`[{"LandNr":"CH"}]`), Expires: time.Now().Add(time.Hour)})
+
+	// Entries survive a restart
+	reopened, _ := NewDiskCache(dir, 0)
+	entry, ok := reopened.Get("DB|ADR/Land?")
+	if !ok || string(entry.Body) != `[{"LandNr":"CH"}]` || entry.Status != 200 {
+		t.Errorf("Expected stored entry, got %+v", entry)
+	}
+
+	reopened.Set(CacheEntry{Key: "DB|LAG/Einheit?", Status: 200, Body: []byte(`[]`), Expires: time.Now().Add(time.Hour)})
+	reopened.DeletePrefix("DB|ADR/")
+	if _, ok := reopened.Get("DB|ADR/Land?"); ok {
+		t.Errorf("Expected entry to be deleted")
+	}
+	if _, ok := reopened.Get("DB|LAG/Einheit?"); !ok {
+		t.Errorf("Expected entry of another endpoint to be kept")
+	}
+	reopened.DeletePrefix("DB|LAG/")
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Expected all files to be deleted by the index, got %d", len(files))
+	}
+
+	// The least recently used files are evicted
+	limited, _ := NewDiskCache(t.TempDir(), 500)
+	body := []byte(strings.Repeat("x", 100))
+	limited.Set(CacheEntry{Key: "a", Body: body})
+	limited.Set(CacheEntry{Key: "b", Body: body})
+	limited.Get("a")
+	limited.Set(CacheEntry{Key: "c", Body: body})
+	if _, ok := limited.Get("b"); ok {
+		t.Errorf("Expected least recently used entry b to be evicted")
+	}
+	if _, ok := limited.Get("a"); !ok {
+		t.Errorf("Expected recently used entry a to be kept")
+	}
+	if _, ok := limited.Get("c"); !ok {
+		t.Errorf("Expected new entry c to be kept")
+	}
+}
@@ -0,0 +1,144 @@
+package proffixrest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+)
+
+// ChangeSet computes a minimal PATCH body with only the fields that differ between original and modified.
+// Both records can be maps or structs; they are compared in their JSON representation.
+//
+//   - Fields which are new or changed in modified are included with their new value.
+//   - Fields of original which are missing in modified (e.g. set to nil with omitempty) are included as null.
+//   - Nested objects are compared recursively and only their changed fields are included.
+//   - Arrays are included as a whole if any element changed.
+//   - Numbers are compared by value, so 145 equals 145.00 and "145.00".
+//
+// An empty map means nothing changed.
+func ChangeSet(original interface{}, modified interface{}) (map[string]interface{}, error) {
+	orig, err := toJSONMap(original)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := toJSONMap(modified)
+	if err != nil {
+		return nil, err
+	}
+	return diffMaps(orig, mod), nil
+}
+
+// toJSONMap converts a map or struct to its JSON object representation with numbers as json.Number
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, &PxError{Message: fmt.Sprintf("JSON Encoding failed: %s", err)}
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return m, nil
+	}
+	if err := unmarshalUseNumber(data, &m); err != nil {
+		return nil, &PxError{Message: fmt.Sprintf("ChangeSet needs a JSON object: %s", err)}
+	}
+	return m, nil
+}
+
+// diffMaps returns the changes from orig to mod
+func diffMaps(orig, mod map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+
+	for key, newValue := range mod {
+		oldValue, exists := orig[key]
+		if !exists {
+			// Null for an unknown field is not a change
+			if newValue != nil {
+				changes[key] = newValue
+			}
+			continue
+		}
+
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			if nested := diffMaps(oldMap, newMap); len(nested) > 0 {
+				changes[key] = nested
+			}
+			continue
+		}
+
+		if !valuesEqual(oldValue, newValue) {
+			changes[key] = newValue
+		}
+	}
+
+	for key, oldValue := range orig {
+		if _, exists := mod[key]; !exists && oldValue != nil {
+			changes[key] = nil
+		}
+	}
+
+	return changes
+}
+
+// valuesEqual compares two decoded JSON values; numbers are compared by their decimal value
+func valuesEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, exists := bv[key]
+			if !exists || !valuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		return numberEqual(av, b)
+	default:
+		if n, ok := b.(json.Number); ok {
+			return numberEqual(n, a)
+		}
+		return a == b
+	}
+}
+
+// numberEqual compares a JSON number with a number or a numeric string
+func numberEqual(n json.Number, other interface{}) bool {
+	var s string
+	switch ov := other.(type) {
+	case json.Number:
+		s = ov.String()
+	case string:
+		s = ov
+	default:
+		return false
+	}
+
+	a, errA := decimal.NewFromJSONNumber(n)
+	b, errB := decimal.NewFromString(s)
+	if errA != nil || errB != nil {
+		return n.String() == s
+	}
+	return a.Equal(b)
+}
@@ -0,0 +1,84 @@
+package proffixrest
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestChangeSet_Maps(t *testing.T) {
+	original := map[string]interface{}{
+		"AdressNr": 276,
+		"Name":     "EYX AG",
+		"Vorname":  "Hans",
+		"Umsatz":   145,
+		"Land":     map[string]interface{}{"LandNr": "CH", "Name": "Schweiz"},
+		"Gruppen":  []interface{}{"A", "B"},
+		"Zusatz":   nil,
+	}
+	modified := map[string]interface{}{
+		"AdressNr": 276,
+		"Name":     "EYX Holding AG",
+		"Umsatz":   "145.00",
+		"Land":     map[string]interface{}{"LandNr": "DE", "Name": "Schweiz"},
+		"Gruppen":  []interface{}{"A", "C"},
+		"Ort":      "Zürich",
+		"Telefon":  nil,
+	}
+
+	changes, err := ChangeSet(original, modified)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]interface{}{
+		"Name":    "EYX Holding AG",
+		"Vorname": nil,
+		"Land":    map[string]interface{}{"LandNr": "DE"},
+		"Gruppen": []interface{}{"A", "C"},
+		"Ort":     "Zürich",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Expected %v, got %v", want, changes)
+	}
+}
+
+func TestChangeSet_Structs(t *testing.T) {
+	type adresse struct {
+		AdressNr int      `json:"AdressNr"`
+		Name     string   `json:"Name"`
+		Vorname  *string  `json:"Vorname,omitempty"`
+		Umsatz   float64  `json:"Umsatz"`
+		Tags     []string `json:"Tags"`
+	}
+	vorname := "Hans"
+	original := adresse{AdressNr: 1, Name: "Muster", Vorname: &vorname, Umsatz: 0.1, Tags: []string{"x"}}
+
+	changes, err := ChangeSet(original, original)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v (%v)", changes, err)
+	}
+
+	modified := original
+	modified.Vorname = nil
+	modified.Umsatz = 0.2
+	changes, err = ChangeSet(&original, &modified)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(changes) != 2 || changes["Vorname"] != nil || changes["Umsatz"] != json.Number("0.2") {
+		t.Errorf("Expected Vorname null and Umsatz 0.2, got %v", changes)
+	}
+	if _, ok := changes["Vorname"]; !ok {
+		t.Errorf("Expected Vorname to be sent as null")
+	}
+}
+
+func TestChangeSet_Invalid(t *testing.T) {
+	if _, err := ChangeSet([]int{1}, map[string]interface{}{}); err == nil {
+		t.Errorf("Expected error for non-object original")
+	}
+	if _, err := ChangeSet(nil, map[string]interface{}{"Name": "Neu"}); err != nil {
+		t.Errorf("Expected nil original to be treated as empty record, got %v", err)
+	}
+}
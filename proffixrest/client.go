@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Version of Wrapper
@@ -32,6 +33,10 @@ type Client struct {
 	pxSessionID      string
 	isLoggedIn       bool
 	logoutInProgress atomic.Bool
+	loginMu          sync.Mutex // Serializes the creation of a session
+	cache            *responseCache
+	flights          *flightGroup
+	lists            listCatalog
 }
 
 // LoginStruct represents the login payload for the PROFFIX REST-API.
@@ -85,6 +90,10 @@ func NewClient(restURL string, apiUser string, apiPassword string, apiDatabase s
 		options.Batchsize = 200
 	}
 
+	if options.ListCacheTTL == 0 {
+		options.ListCacheTTL = 10 * time.Minute
+	}
+
 	// Build per-client transport and client (or use injected client)
 	var httpClient *http.Client
 	if options.HTTPClient != nil {
@@ -102,6 +111,11 @@ func NewClient(restURL string, apiUser string, apiPassword string, apiDatabase s
 	path := options.APIPrefix + options.Version + "/"
 	parsedURL.Path = path
 
+	var flights *flightGroup
+	if options.DedupeGets {
+		flights = &flightGroup{flights: map[string]*flight{}}
+	}
+
 	return &Client{
 		restURL:   parsedURL,
 		Benutzer:  apiUser,
@@ -110,6 +124,8 @@ func NewClient(restURL string, apiUser string, apiPassword string, apiDatabase s
 		Module:    apiModule,
 		option:    options,
 		client:    httpClient,
+		cache:     newResponseCache(options.Cache),
+		flights:   flights,
 	}, nil
 }
 
@@ -150,7 +166,9 @@ func (c *Client) createNewPxSessionID(ctx context.Context) (sessionid string, er
 	resp, err := c.client.Do(req)
 	if err != nil {
 		if resp == nil {
-			return "", NewPxError(nil, 0, c.option.LoginEndpoint)
+			pxErr := NewPxError(nil, 0, c.option.LoginEndpoint)
+			pxErr.cause = err
+			return "", pxErr
 		}
 		pxErr := NewPxError(resp.Body, resp.StatusCode, c.option.LoginEndpoint)
 		if resp.Body != nil {
@@ -180,25 +198,36 @@ func (c *Client) createNewPxSessionID(ctx context.Context) (sessionid string, er
 }
 
 // Login ensures the client has a valid PxSessionID by creating one if needed.
+// Concurrent callers wait for a single login instead of creating several sessions.
 func (c *Client) Login(ctx context.Context) error {
-	// DEBUG: Log Login entry
-	log.Printf("DEBUG Login: ENTER isLoggedIn=%v, pxSessionID=%s", c.isLoggedIn, c.GetPxSessionID())
-
 	// If Pxsessionid doesnt yet exists create a new one
 	c.mu.RLock()
 	loggedIn := c.isLoggedIn
 	c.mu.RUnlock()
-	if !loggedIn {
-		log.Printf("DEBUG Login: Not logged in, creating new session...")
-		sessionid, err := c.createNewPxSessionID(ctx)
-		log.Printf("DEBUG Login: createNewPxSessionID returned sessionid=%s, err=%v", sessionid, err)
-		_ = sessionid // already stored in client
-		return err
+
+	// DEBUG: Log Login entry
+	log.Printf("DEBUG Login: ENTER isLoggedIn=%v, pxSessionID=%s", loggedIn, c.GetPxSessionID())
+	if loggedIn {
+		// If Pxsessionid already exists return stored value
+		log.Printf("DEBUG Login: Already logged in, skipping login")
+		return nil
+	}
+
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	// Another caller may have logged in while waiting
+	c.mu.RLock()
+	loggedIn = c.isLoggedIn
+	c.mu.RUnlock()
+	if loggedIn {
+		return nil
 	}
 
-	// If Pxsessionid already exists return stored value
-	log.Printf("DEBUG Login: Already logged in, skipping login")
-	return nil
+	log.Printf("DEBUG Login: Not logged in, creating new session...")
+	sessionid, err := c.createNewPxSessionID(ctx)
+	log.Printf("DEBUG Login: createNewPxSessionID returned sessionid=%s, err=%v", sessionid, err)
+	return err
 }
 
 // ServiceLogin activates the client using an existing PxSessionID provided by the caller.
@@ -255,6 +284,14 @@ func (c *Client) Logout(ctx context.Context) (int, error) {
 
 }
 
+// resetSession clears the local session so the next request logs in again
+func (c *Client) resetSession() {
+	c.mu.Lock()
+	c.pxSessionID = ""
+	c.isLoggedIn = false
+	c.mu.Unlock()
+}
+
 // Request Method
 // Building the Request Method for Client
 func (c *Client) request(ctx context.Context, method, endpoint string, params url.Values, isFile bool, data interface{}) (io.ReadCloser, http.Header, int, error) {
@@ -338,10 +375,20 @@ func (c *Client) request(ctx context.Context, method, endpoint string, params ur
 	req.Header.Set("pxsessionid", c.GetPxSessionID())
 	// Set User-Agent for all requests
 	req.Header.Set("User-Agent", c.option.UserAgent)
+	// Additional headers of the caller, e.g. Range
+	if extra, ok := ctx.Value(requestHeaderKey{}).(http.Header); ok {
+		for key, values := range extra {
+			req.Header[key] = values
+		}
+	}
 
 	resp, err := c.client.Do(req)
 
 	if resp != nil && (resp.StatusCode >= 300 || resp.StatusCode < 200) {
+		// The session expired or is invalid -> the next request logs in again
+		if resp.StatusCode == http.StatusUnauthorized {
+			c.resetSession()
+		}
 		pxErr := NewPxError(resp.Body, resp.StatusCode, endpoint)
 		if resp.Body != nil {
 			_ = resp.Body.Close()
@@ -358,9 +405,8 @@ func (c *Client) request(ctx context.Context, method, endpoint string, params ur
 		return resp.Body, resp.Header, resp.StatusCode, nil
 	}
 
-	// If everything fails -> logout
-	_, _ = c.Logout(ctx)
-	return nil, nil, 0, &PxError{Message: fmt.Sprintf("%v", err)}
+	// Transport errors and cancellations of the caller do not end the session on the server, so it is kept
+	return nil, nil, 0, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("%v", err), cause: err}
 
 }
 
@@ -378,6 +424,7 @@ func (c *Client) Post(ctx context.Context, endpoint string, data interface{}) (i
 	if err != nil {
 		return request, header, statuscode, err
 	}
+	c.invalidateCache(endpoint)
 	return request, header, statuscode, nil
 
 }
@@ -396,11 +443,32 @@ func (c *Client) Put(ctx context.Context, endpoint string, data interface{}) (io
 	if err != nil {
 		return request, header, statuscode, err
 	}
+	c.invalidateCache(endpoint)
 	return request, header, statuscode, nil
 }
 
 // Get sends a GET request to the PROFFIX REST-API.
+// If a cache is configured, responses of matching endpoints are served from the cache.
+// With DedupeGets, identical concurrent requests share a single request (except file downloads of PRO/Datei).
 func (c *Client) Get(ctx context.Context, endpoint string, params url.Values) (io.ReadCloser, http.Header, int, error) {
+	fetch := func() (io.ReadCloser, http.Header, int, error) {
+		return c.get(ctx, endpoint, params)
+	}
+	if key, ok := c.dedupeKey(ctx, endpoint, params); ok {
+		fetch = func() (io.ReadCloser, http.Header, int, error) {
+			return c.flights.do(ctx, key, endpoint, func(ctx context.Context) (io.ReadCloser, http.Header, int, error) {
+				return c.get(ctx, endpoint, params)
+			})
+		}
+	}
+	if c.cache != nil {
+		return c.cachedGet(ctx, endpoint, params, fetch)
+	}
+	return fetch()
+}
+
+// get sends a GET request without cache
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values) (io.ReadCloser, http.Header, int, error) {
 
 	err := c.Login(ctx)
 
@@ -409,8 +477,11 @@ func (c *Client) Get(ctx context.Context, endpoint string, params url.Values) (i
 	}
 
 	// DEBUG: Log client state before request to diagnose state corruption
+	c.mu.RLock()
+	loggedIn := c.isLoggedIn
+	c.mu.RUnlock()
 	log.Printf("DEBUG Get: PxSessionID=%s, restURL=%s, isLoggedIn=%v, endpoint=%s",
-		c.GetPxSessionID(), c.restURL.String(), c.isLoggedIn, endpoint)
+		c.GetPxSessionID(), c.restURL.String(), loggedIn, endpoint)
 
 	request, header, statuscode, err := c.request(ctx, "GET", endpoint, params, false, nil)
 	if err != nil {
@@ -435,6 +506,7 @@ func (c *Client) Patch(ctx context.Context, endpoint string, data interface{}) (
 	if err != nil {
 		return request, header, statuscode, err
 	}
+	c.invalidateCache(endpoint)
 	return request, header, statuscode, nil
 }
 
@@ -450,6 +522,7 @@ func (c *Client) Delete(ctx context.Context, endpoint string) (io.ReadCloser, ht
 	if err != nil {
 		return request, header, statuscode, err
 	}
+	c.invalidateCache(endpoint)
 	return request, header, statuscode, nil
 }
 
@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Define struct (AdressNr as string just for convenience)
@@ -461,3 +465,81 @@ func TestClient_LoginWithFalsePxSessionId(t *testing.T) {
 		t.Errorf("Expected no error for Logout. Got '%v'", err)
 	}
 }
+
+func TestClient_TimeoutKeepsSession(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint != "ADR/Langsam" {
+			return false
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		return true
+	}
+	c := px.client(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, _, err := c.Get(ctx, "ADR/Langsam", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if c.GetPxSessionID() != "session-1" {
+		t.Errorf("Expected session to be kept after a timeout, got %q", c.GetPxSessionID())
+	}
+
+	rc, _, _, err := c.Get(context.Background(), "ADR/Adresse", nil)
+	closeBody(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n := px.loginCount(); n != 1 {
+		t.Errorf("Expected 1 login, got %d", n)
+	}
+}
+
+func TestClient_UnauthorizedResetsSession(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint != "ADR/Abgelaufen" {
+			return false
+		}
+		writePxError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Session abgelaufen")
+		return true
+	}
+	c := px.client(nil)
+
+	if _, _, _, err := c.Get(context.Background(), "ADR/Abgelaufen", nil); err == nil {
+		t.Fatalf("Expected error for 401")
+	}
+	rc, _, _, err := c.Get(context.Background(), "ADR/Adresse", nil)
+	closeBody(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n := px.loginCount(); n != 2 {
+		t.Errorf("Expected a new login after 401, got %d logins", n)
+	}
+}
+
+func TestClient_ConcurrentLogin(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	c := px.client(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Login(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := px.loginCount(); n != 1 {
+		t.Errorf("Expected concurrent callers to share 1 login, got %d", n)
+	}
+}
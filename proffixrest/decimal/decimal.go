@@ -0,0 +1,348 @@
+// Package decimal provides an exact decimal type for amounts and quantities of the PROFFIX REST-API.
+//
+// PROFFIX sends amounts as JSON numbers. Decoding them into float64 introduces rounding errors
+// (0.1 + 0.2 != 0.3), which breaks reconciliations. Decimal decodes the number literal exactly,
+// calculates without loss and marshals back with the same precision.
+package decimal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number: value * 10^-scale. The zero value is 0.
+// Decimals are immutable; all operations return a new Decimal.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+// Zero is the decimal 0.
+var Zero = Decimal{}
+
+// maxScale limits the exponent of parsed numbers, so a crafted input like "1e999999999" cannot allocate huge numbers
+const maxScale = 10000
+
+var (
+	bigTen = big.NewInt(10)
+	bigTwo = big.NewInt(2)
+)
+
+// New creates the decimal value * 10^-scale, e.g. New(1995, 2) is 19.95.
+func New(value int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{value: new(big.Int).Mul(big.NewInt(value), pow10(-scale))}
+	}
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// NewFromInt creates a decimal from an integer.
+func NewFromInt(i int64) Decimal {
+	return New(i, 0)
+}
+
+// NewFromFloat creates a decimal from the shortest representation of f.
+// Use it only for values which are not available as string or JSON number.
+func NewFromFloat(f float64) (Decimal, error) {
+	return NewFromString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// NewFromString parses a decimal like "-1234.50" or "1.5e3".
+func NewFromString(s string) (Decimal, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+
+	exp := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Zero, fmt.Errorf("decimal: invalid exponent in %q", orig)
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	digits := intPart + fracPart
+	if strings.HasPrefix(digits, "+") || strings.HasPrefix(digits, "-") {
+		digits = digits[1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" || strings.ContainsAny(fracPart, "+-") {
+		return Zero, fmt.Errorf("decimal: invalid number %q", orig)
+	}
+
+	value, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Zero, fmt.Errorf("decimal: invalid number %q", orig)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale > maxScale || scale < -maxScale {
+		return Zero, fmt.Errorf("decimal: exponent out of range in %q", orig)
+	}
+	if scale < 0 {
+		value.Mul(value, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+// RequireFromString parses a decimal and panics on invalid input. Use it for constants.
+func RequireFromString(s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewFromJSONNumber creates a decimal from a json.Number as returned by a decoder with UseNumber.
+func NewFromJSONNumber(n json.Number) (Decimal, error) {
+	return NewFromString(n.String())
+}
+
+// bigValue returns the unscaled value, never nil
+func (d Decimal) bigValue() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// rescale returns the unscaled value for a larger or equal scale
+func (d Decimal) rescale(scale int32) *big.Int {
+	v := new(big.Int).Set(d.bigValue())
+	if scale > d.scale {
+		v.Mul(v, pow10(scale-d.scale))
+	}
+	return v
+}
+
+// align returns the unscaled values of d and d2 with a common scale
+func align(d, d2 Decimal) (a, b *big.Int, scale int32) {
+	scale = d.scale
+	if d2.scale > scale {
+		scale = d2.scale
+	}
+	return d.rescale(scale), d2.rescale(scale), scale
+}
+
+// Add returns d + d2.
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{value: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - d2.
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{value: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d * d2.
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.bigValue(), d2.bigValue()), scale: d.scale + d2.scale}
+}
+
+// Div returns d / d2 rounded half away from zero to the given number of decimal places.
+func (d Decimal) Div(d2 Decimal, places int32) (Decimal, error) {
+	if d2.IsZero() {
+		return Zero, fmt.Errorf("decimal: division by zero")
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	// (v1 / 10^s1) / (v2 / 10^s2) * 10^places = v1 * 10^(s2+places) / (v2 * 10^s1)
+	num := new(big.Int).Set(d.bigValue())
+	den := new(big.Int).Set(d2.bigValue())
+	if shift := d2.scale + places - d.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return Decimal{value: quoRound(num, den), scale: places}, nil
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.bigValue()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.bigValue()), scale: d.scale}
+}
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	return d.bigValue().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and d2 and returns -1, 0 or +1.
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and d2 have the same value (1.5 equals 1.50).
+func (d Decimal) Equal(d2 Decimal) bool {
+	return d.Cmp(d2) == 0
+}
+
+// LessThan reports whether d < d2.
+func (d Decimal) LessThan(d2 Decimal) bool {
+	return d.Cmp(d2) < 0
+}
+
+// GreaterThan reports whether d > d2.
+func (d Decimal) GreaterThan(d2 Decimal) bool {
+	return d.Cmp(d2) > 0
+}
+
+// Round rounds half away from zero (commercial rounding) to the given number of decimal places.
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if d.scale <= places {
+		return Decimal{value: d.rescale(places), scale: places}
+	}
+	return Decimal{value: quoRound(d.bigValue(), pow10(d.scale-places)), scale: places}
+}
+
+// RoundCash rounds half away from zero to a multiple of step, e.g. RoundCash(New(5, 2)) rounds to 0.05.
+func (d Decimal) RoundCash(step Decimal) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+	a, b, scale := align(d, step)
+	n := quoRound(a, b)
+	// The result is a multiple of step, so it has no more places than step
+	return Decimal{value: n.Mul(n, b), scale: scale}.Round(step.scale)
+}
+
+// Round5Rappen rounds to 5 Rappen (0.05) as used for CHF cash amounts, e.g. 1.025 -> 1.05 and 1.02 -> 1.00.
+func (d Decimal) Round5Rappen() Decimal {
+	return d.RoundCash(New(5, 2))
+}
+
+// Scale returns the number of decimal places.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Float64 returns the nearest float64 value and whether it is exact, i.e. converts back to d.
+// Values beyond the float64 range return ±Inf and false.
+func (d Decimal) Float64() (f float64, exact bool) {
+	r := new(big.Rat).SetInt(d.bigValue())
+	if d.scale > 0 {
+		r.Quo(r, new(big.Rat).SetInt(pow10(d.scale)))
+	} else if d.scale < 0 {
+		r.Mul(r, new(big.Rat).SetInt(pow10(-d.scale)))
+	}
+	f, _ = r.Float64()
+	if math.IsInf(f, 0) {
+		return f, false
+	}
+	back, err := NewFromString(strconv.FormatFloat(f, 'g', -1, 64))
+	return f, err == nil && back.Equal(d)
+}
+
+// String returns the decimal with its scale, e.g. "145.00".
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.bigValue()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) - len(s) + 1; pad > 0 {
+			s = strings.Repeat("0", pad) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if d.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// StringFixed returns the decimal rounded to the given number of decimal places.
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places).String()
+}
+
+// MarshalJSON encodes the decimal as JSON number without losing precision.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or a quoted number. Null results in 0.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		*d = Zero
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+		if s == "" {
+			*d = Zero
+			return nil
+		}
+	}
+	parsed, err := NewFromString(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalText encodes the decimal as text (e.g. for CSV or map keys).
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes a decimal from text.
+func (d *Decimal) UnmarshalText(b []byte) error {
+	parsed, err := NewFromString(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// quoRound returns num / den rounded half away from zero
+func quoRound(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// Round up if 2*|r| >= |den|
+	r2 := new(big.Int).Mul(new(big.Int).Abs(r), bigTwo)
+	if r2.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign()*den.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// pow10 returns 10^n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,164 @@
+package decimal
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestNewFromString(t *testing.T) {
+	cases := map[string]string{
+		"145.00":  "145.00",
+		"-0.05":   "-0.05",
+		"1.5e3":   "1500",
+		"1.5E-2":  "0.015",
+		".5":      "0.5",
+		"+7":      "7",
+		"0.00001": "0.00001",
+	}
+	for in, want := range cases {
+		d, err := NewFromString(in)
+		if err != nil {
+			t.Errorf("NewFromString(%q): expected no error, got %v", in, err)
+			continue
+		}
+		if d.String() != want {
+			t.Errorf("NewFromString(%q): expected %s, got %s", in, want, d.String())
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "--1", "1e", "1.-5", "0.5e-2147483647", "1e2147483647", "1e99999"} {
+		if _, err := NewFromString(in); err == nil {
+			t.Errorf("NewFromString(%q): expected error", in)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := RequireFromString("0.1")
+	b := RequireFromString("0.2")
+
+	if !a.Add(b).Equal(RequireFromString("0.3")) {
+		t.Errorf("Expected 0.1 + 0.2 = 0.3, got %s", a.Add(b))
+	}
+	if a.Sub(b).String() != "-0.1" {
+		t.Errorf("Expected 0.1 - 0.2 = -0.1, got %s", a.Sub(b))
+	}
+	if RequireFromString("19.95").Mul(NewFromInt(3)).String() != "59.85" {
+		t.Errorf("Expected 19.95 * 3 = 59.85, got %s", RequireFromString("19.95").Mul(NewFromInt(3)))
+	}
+
+	q, err := NewFromInt(10).Div(NewFromInt(3), 2)
+	if err != nil || q.String() != "3.33" {
+		t.Errorf("Expected 10 / 3 = 3.33, got %s (%v)", q, err)
+	}
+	q, _ = NewFromInt(-2).Div(NewFromInt(3), 2)
+	if q.String() != "-0.67" {
+		t.Errorf("Expected -2 / 3 = -0.67, got %s", q)
+	}
+	if _, err := a.Div(Zero, 2); err == nil {
+		t.Errorf("Expected error on division by zero")
+	}
+
+	if a.Neg().Abs().String() != "0.1" || a.Neg().Sign() != -1 || !Zero.IsZero() {
+		t.Errorf("Unexpected Neg/Abs/Sign/IsZero results")
+	}
+	if !a.LessThan(b) || !b.GreaterThan(a) || a.Cmp(RequireFromString("0.100")) != 0 {
+		t.Errorf("Unexpected comparison results")
+	}
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		in     string
+		places int32
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"-1.005", 2, "-1.01"},
+		{"1.004", 2, "1.00"},
+		{"2.5", 0, "3"},
+		{"7", 2, "7.00"},
+	}
+	for _, c := range cases {
+		if got := RequireFromString(c.in).Round(c.places).String(); got != c.want {
+			t.Errorf("Round(%s, %d): expected %s, got %s", c.in, c.places, c.want, got)
+		}
+	}
+}
+
+func TestRound5Rappen(t *testing.T) {
+	cases := map[string]string{
+		"1.02":   "1.00",
+		"1.025":  "1.05",
+		"1.075":  "1.10",
+		"1.07":   "1.05",
+		"-1.025": "-1.05",
+		"12.345": "12.35",
+		"3":      "3.00",
+	}
+	for in, want := range cases {
+		if got := RequireFromString(in).Round5Rappen().String(); got != want {
+			t.Errorf("Round5Rappen(%s): expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type buchung struct {
+		Betrag *Decimal `json:"Betrag"`
+		Menge  Decimal  `json:"Menge"`
+		Preis  Decimal  `json:"Preis"`
+	}
+
+	var b buchung
+	if err := json.Unmarshal([]byte(`{"Betrag":1234567890123456789.12,"Menge":"2.50","Preis":null}`), &b); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if b.Betrag.String() != "1234567890123456789.12" {
+		t.Errorf("Expected exact Betrag, got %s", b.Betrag)
+	}
+	if b.Menge.String() != "2.50" || !b.Preis.IsZero() {
+		t.Errorf("Unexpected Menge/Preis: %s %s", b.Menge, b.Preis)
+	}
+
+	out, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(out) != `{"Betrag":1234567890123456789.12,"Menge":2.50,"Preis":0}` {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Menge":"abc"}`), &b); err == nil {
+		t.Errorf("Expected error for invalid number")
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	f, exact := RequireFromString("0.25").Float64()
+	if f != 0.25 || !exact {
+		t.Errorf("Expected exact 0.25, got %v %v", f, exact)
+	}
+	if _, exact := RequireFromString("0.1000000000000000000001").Float64(); exact {
+		t.Errorf("Expected inexact conversion")
+	}
+
+	if f, exact := RequireFromString("1e400").Float64(); !math.IsInf(f, 1) || exact {
+		t.Errorf("Expected +Inf for 1e400, got %v %v", f, exact)
+	}
+	if f, exact := RequireFromString("-1e400").Float64(); !math.IsInf(f, -1) || exact {
+		t.Errorf("Expected -Inf for -1e400, got %v %v", f, exact)
+	}
+	if f, exact := RequireFromString("1e-400").Float64(); f != 0 || exact {
+		t.Errorf("Expected inexact 0 for 1e-400, got %v %v", f, exact)
+	}
+	if f, exact := RequireFromString("0.1").Float64(); f != 0.1 || !exact {
+		t.Errorf("Expected 0.1 to convert back exactly, got %v %v", f, exact)
+	}
+
+	d, err := NewFromFloat(0.1)
+	if err != nil || d.String() != "0.1" {
+		t.Errorf("Expected 0.1, got %s (%v)", d, err)
+	}
+}
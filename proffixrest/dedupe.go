@@ -0,0 +1,131 @@
+package proffixrest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// flightGroup collapses identical concurrent GET requests into a single request
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a shared GET request and its waiters
+type flight struct {
+	done   chan struct{}
+	refs   int // Waiters which still need the response
+	cancel context.CancelFunc
+
+	body   []byte
+	header http.Header
+	status int
+	err    error
+}
+
+// dedupeKey returns the key under which a GET request is shared, or false if it must be sent on its own.
+// Context values which change the request are part of the key.
+func (c *Client) dedupeKey(ctx context.Context, endpoint string, params url.Values) (string, bool) {
+	if c.flights == nil {
+		return "", false
+	}
+	// Files are streamed to the caller instead of being buffered for all waiters
+	if strings.HasPrefix(strings.ToUpper(endpoint)+"/", "PRO/DATEI/") {
+		return "", false
+	}
+
+	key := c.cacheKey(endpoint, params)
+	if ctx.Value(noCacheKey{}) != nil {
+		key += "|nocache"
+	}
+	if header, ok := ctx.Value(requestHeaderKey{}).(http.Header); ok {
+		key += "|" + url.Values(header).Encode()
+	}
+	return key, true
+}
+
+// do returns the response of the request of key, sending it only if no identical request is in flight.
+// Every waiter gets its own reader of the body. The shared request is cancelled when the last waiter is gone.
+func (g *flightGroup) do(ctx context.Context, key string, endpoint string, fetch func(ctx context.Context) (io.ReadCloser, http.Header, int, error)) (io.ReadCloser, http.Header, int, error) {
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if ok {
+		f.refs++
+	} else {
+		// The request must outlive the waiter which started it, but keeps its values (e.g. WithoutCache)
+		sharedCtx, cancel := context.WithCancel(detachedContext{ctx})
+		f = &flight{done: make(chan struct{}), refs: 1, cancel: cancel}
+		g.flights[key] = f
+		go g.run(sharedCtx, key, endpoint, f, fetch)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return nil, f.header.Clone(), f.status, f.err
+		}
+		return io.NopCloser(bytes.NewReader(f.body)), f.header.Clone(), f.status, nil
+	case <-ctx.Done():
+		g.leave(key, f)
+		return nil, nil, 0, toPxError(ctx.Err(), endpoint)
+	}
+}
+
+// run sends the shared request and reads the whole body for the waiters
+func (g *flightGroup) run(ctx context.Context, key string, endpoint string, f *flight, fetch func(ctx context.Context) (io.ReadCloser, http.Header, int, error)) {
+	defer f.cancel()
+
+	rc, header, status, err := fetch(ctx)
+	if err == nil && rc != nil {
+		f.body, err = io.ReadAll(rc)
+		if err != nil {
+			err = toPxError(err, endpoint)
+		}
+	}
+	closeBody(rc)
+	f.header, f.status, f.err = header, status, err
+
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// leave removes a waiter; the last one cancels the request so later callers start a new one
+func (g *flightGroup) leave(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	f.refs--
+	if f.refs == 0 {
+		if g.flights[key] == f {
+			delete(g.flights, key)
+		}
+		f.cancel()
+	}
+}
+
+// detachedContext keeps the values of a context but not its cancellation and deadline
+type detachedContext struct {
+	parent context.Context
+}
+
+// Deadline returns no deadline
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+// Done returns nil, the context is never cancelled
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+// Err returns nil, the context is never cancelled
+func (detachedContext) Err() error { return nil }
+
+// Value returns the value of the parent context
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
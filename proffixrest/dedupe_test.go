@@ -0,0 +1,229 @@
+package proffixrest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingFake returns a fake server whose GET requests of ADR/Adresse/1 wait until release is closed.
+// The context errors of the blocked requests are sent to cancelled.
+func blockingFake(t *testing.T) (px *fakePX, release chan struct{}, cancelled chan error) {
+	t.Helper()
+	px = newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": "1", "Name": "Muster AG"})
+	release = make(chan struct{})
+	cancelled = make(chan error, 10)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method != http.MethodGet || endpoint != "ADR/Adresse/1" {
+			return false
+		}
+		// The server only notices a closed connection after the body was read
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-release:
+			return false
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+			return true
+		}
+	}
+	return px, release, cancelled
+}
+
+// waiters returns the number of waiters of the flight of endpoint
+func waiters(c *Client, endpoint string) int {
+	c.flights.mu.Lock()
+	defer c.flights.mu.Unlock()
+	if f, ok := c.flights.flights[c.cacheKey(endpoint, nil)]; ok {
+		return f.refs
+	}
+	return 0
+}
+
+// waitFor polls cond until it is true or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Condition not met in time")
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestDedupeGets_SharesRequest(t *testing.T) {
+	px, release, _ := blockingFake(t)
+	c := px.client(&Options{DedupeGets: true})
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rc, _, _, err := c.Get(context.Background(), "ADR/Adresse/1", nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			data, err := ReaderToByte(rc)
+			bodies[i], errs[i] = string(data), err
+		}(i)
+	}
+
+	waitFor(t, func() bool { return waiters(c, "ADR/Adresse/1") == n })
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil || bodies[i] != bodies[0] || bodies[i] == "" {
+			t.Errorf("Expected identical body for waiter %d, got %q %v", i, bodies[i], errs[i])
+		}
+	}
+	if calls := px.callCount("GET", "ADR/Adresse/1"); calls != 1 {
+		t.Errorf("Expected 1 request, got %d", calls)
+	}
+
+	// Finished requests are not reused
+	rc, _, _, err := c.Get(context.Background(), "ADR/Adresse/1", nil)
+	closeBody(rc)
+	if err != nil || px.callCount("GET", "ADR/Adresse/1") != 2 {
+		t.Errorf("Expected a new request after the shared one finished, got %v", err)
+	}
+
+	// Different params are separate requests
+	rc, _, _, _ = c.Get(context.Background(), "ADR/Adresse/1", url.Values{"Fields": {"Name"}})
+	closeBody(rc)
+	if calls := px.callCount("GET", "ADR/Adresse/1"); calls != 3 {
+		t.Errorf("Expected 3 requests, got %d", calls)
+	}
+}
+
+func TestDedupeGets_Cancellation(t *testing.T) {
+	px, release, cancelled := blockingFake(t)
+	c := px.client(&Options{DedupeGets: true})
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The first waiter starts the request and cancels; the second still gets the response
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		rc, _, _, err := c.Get(ctx, "ADR/Adresse/1", nil)
+		closeBody(rc)
+		first <- err
+	}()
+	waitFor(t, func() bool { return px.callCount("GET", "ADR/Adresse/1") == 1 })
+
+	second := make(chan error, 1)
+	go func() {
+		rc, _, _, err := c.Get(context.Background(), "ADR/Adresse/1", nil)
+		if err == nil {
+			_, err = ReaderToByte(rc)
+		}
+		second <- err
+	}()
+	waitFor(t, func() bool { return waiters(c, "ADR/Adresse/1") == 2 })
+
+	cancel()
+	if err := <-first; err == nil {
+		t.Errorf("Expected error for the cancelled waiter")
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("Expected response for the remaining waiter, got %v", err)
+	}
+	select {
+	case err := <-cancelled:
+		t.Errorf("Expected shared request not to be cancelled, got %v", err)
+	default:
+	}
+}
+
+func TestDedupeGets_LastWaiterCancels(t *testing.T) {
+	px, _, cancelled := blockingFake(t)
+	c := px.client(&Options{DedupeGets: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := c.Get(ctx, "ADR/Adresse/1", nil)
+		done <- err
+	}()
+	waitFor(t, func() bool { return px.callCount("GET", "ADR/Adresse/1") == 1 })
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Errorf("Expected error for the cancelled waiter")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected shared request to be cancelled with its last waiter")
+	}
+	if waiters(c, "ADR/Adresse/1") != 0 {
+		t.Errorf("Expected cancelled flight to be removed")
+	}
+}
+
+func TestDedupeGets_ContextInKey(t *testing.T) {
+	px, release, _ := blockingFake(t)
+	c := px.client(&Options{DedupeGets: true})
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{context.Background(), WithoutCache(context.Background())} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			if _, _, _, err := c.Get(ctx, "ADR/Adresse/1", nil); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		}(ctx)
+	}
+	// A request without cache is not shared with a plain one
+	waitFor(t, func() bool { return px.callCount("GET", "ADR/Adresse/1") == 2 })
+	close(release)
+	wg.Wait()
+}
+
+func TestDedupeGets_Key(t *testing.T) {
+	c, _ := NewClient("https://example.com", "USR", "pw", "DEMO", nil, &Options{DedupeGets: true})
+	ctx := context.Background()
+
+	plain, ok := c.dedupeKey(ctx, "ADR/Adresse/1", nil)
+	if !ok {
+		t.Fatalf("Expected ADR/Adresse/1 to be deduplicated")
+	}
+	ranged, _ := c.dedupeKey(withRequestHeader(ctx, http.Header{"Range": {"bytes=10-"}}), "ADR/Adresse/1", nil)
+	noCache, _ := c.dedupeKey(WithoutCache(ctx), "ADR/Adresse/1", nil)
+	if ranged == plain || noCache == plain || ranged == noCache {
+		t.Errorf("Expected different keys, got %q, %q and %q", plain, ranged, noCache)
+	}
+
+	// File downloads are never buffered for other waiters
+	for _, endpoint := range []string{"PRO/Datei", "PRO/Datei/abc", "pro/datei/abc"} {
+		if _, ok := c.dedupeKey(ctx, endpoint, nil); ok {
+			t.Errorf("Expected %s not to be deduplicated", endpoint)
+		}
+	}
+
+	plainClient, _ := NewClient("https://example.com", "USR", "pw", "DEMO", nil, nil)
+	if _, ok := plainClient.dedupeKey(ctx, "ADR/Adresse/1", nil); ok {
+		t.Errorf("Expected no deduplication without DedupeGets")
+	}
+}
@@ -0,0 +1,320 @@
+package proffixrest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+// WatermarkStore stores the watermarks of delta pulls by key
+type WatermarkStore interface {
+	// Load returns the watermark of key; ok is false if there is none yet
+	Load(key string) (watermark time.Time, ok bool, err error)
+	// Save stores the watermark of key
+	Save(key string, watermark time.Time) error
+}
+
+// MemoryWatermarkStore keeps watermarks in memory
+type MemoryWatermarkStore struct {
+	mu         sync.Mutex
+	watermarks map[string]time.Time
+}
+
+// NewMemoryWatermarkStore creates an empty MemoryWatermarkStore
+func NewMemoryWatermarkStore() *MemoryWatermarkStore {
+	return &MemoryWatermarkStore{watermarks: map[string]time.Time{}}
+}
+
+// Load returns the watermark of key
+func (s *MemoryWatermarkStore) Load(key string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	watermark, ok := s.watermarks[key]
+	return watermark, ok, nil
+}
+
+// Save stores the watermark of key
+func (s *MemoryWatermarkStore) Save(key string, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watermarks[key] = watermark
+	return nil
+}
+
+// FileWatermarkStore keeps watermarks in a JSON file. The file is replaced atomically on every Save.
+type FileWatermarkStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileWatermarkStore creates a FileWatermarkStore; the file is created on the first Save
+func NewFileWatermarkStore(path string) *FileWatermarkStore {
+	return &FileWatermarkStore{path: path}
+}
+
+// Load returns the watermark of key
+func (s *FileWatermarkStore) Load(key string) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watermarks, err := s.read()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	watermark, ok := watermarks[key]
+	return watermark, ok, nil
+}
+
+// Save stores the watermark of key
+func (s *FileWatermarkStore) Save(key string, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watermarks, err := s.read()
+	if err != nil {
+		return err
+	}
+	watermarks[key] = watermark
+
+	data, err := json.MarshalIndent(watermarks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0o600)
+}
+
+// read reads all watermarks of the file
+func (s *FileWatermarkStore) read() (map[string]time.Time, error) {
+	watermarks := map[string]time.Time{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return watermarks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &watermarks); err != nil {
+		return nil, fmt.Errorf("invalid watermark file %s: %w", s.path, err)
+	}
+	return watermarks, nil
+}
+
+// DeltaOptions configures a DeltaReader
+type DeltaOptions struct {
+	Field     string        // Modification field; every entry must have a valid timestamp. Default is "GeaendertAm"
+	KeyField  string        // Key of the endpoint; sorts entries with the same timestamp for stable paging
+	Key       string        // Key of the watermark in the store. Default is the endpoint
+	Filter    string        // Additional PROFFIX filter, e.g. "Gruppe=='SHOP'"
+	Overlap   time.Duration // Entries modified up to this duration before the watermark are read again (clock skew)
+	Initial   time.Time     // Lower bound if there is no watermark yet. Zero reads all entries
+	Batchsize int           // Maximum number of entries per batch. Default is the Batchsize of the client
+}
+
+// DeltaReader reads the entries of an endpoint changed since the last committed watermark
+type DeltaReader struct {
+	client   *Client
+	endpoint string
+	store    WatermarkStore
+	opts     DeltaOptions
+
+	mu     sync.Mutex
+	cursor *deltaCursor // Position after the last committed page while More pages follow
+}
+
+// deltaCursor is the modification time of the last delivered entry and the keys delivered with this time.
+// Entries are sorted by (time, key), so the next page continues exactly after the cursor.
+type deltaCursor struct {
+	modified time.Time
+	keys     map[string]bool
+}
+
+// DeltaBatch is a page of the entries changed since the watermark. Call Commit after processing them
+// to move the watermark forward; otherwise the next Next returns them again.
+type DeltaBatch struct {
+	Data      []byte    // JSON array of the changed entries (like GetBatch)
+	Count     int       // Number of entries, at most Batchsize
+	More      bool      // More changed entries follow; call Next again after Commit
+	Since     time.Time // Lower bound of the query (zero if all entries were read)
+	Watermark time.Time // Latest modification of the entries; saved by Commit
+
+	reader *DeltaReader
+	cursor *deltaCursor
+}
+
+// NewDeltaReader creates a DeltaReader for endpoint which stores its watermark in store.
+func (c *Client) NewDeltaReader(endpoint string, store WatermarkStore, opts *DeltaOptions) *DeltaReader {
+	r := &DeltaReader{client: c, endpoint: endpoint, store: store}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Field == "" {
+		r.opts.Field = "GeaendertAm"
+	}
+	if r.opts.Key == "" {
+		r.opts.Key = endpoint
+	}
+	return r
+}
+
+// Next reads the next page of at most Batchsize entries changed since the watermark minus the overlap,
+// sorted by the modification field and the key. While More is set, the next Next continues after the last
+// committed entry, so no entry at a page boundary is lost or repeated. A new pass starts at the watermark
+// minus the overlap; entries within the overlap are returned again, so consumers must be idempotent.
+func (r *DeltaReader) Next(ctx context.Context) (*DeltaBatch, error) {
+	watermark, ok, err := r.store.Load(r.opts.Key)
+	if err != nil {
+		return nil, &PxError{Endpoint: r.endpoint, Message: fmt.Sprintf("Loading watermark failed: %s", err)}
+	}
+
+	r.mu.Lock()
+	cursor := r.cursor
+	r.mu.Unlock()
+
+	since := r.opts.Initial
+	if ok {
+		since = watermark.Add(-r.opts.Overlap)
+	}
+	if cursor != nil {
+		since = cursor.modified
+	}
+
+	filter := r.opts.Filter
+	if !since.IsZero() {
+		clause := fmt.Sprintf("%s>='%s'", r.opts.Field, pxtime.Format(since))
+		if filter != "" {
+			filter += "," + clause
+		} else {
+			filter = clause
+		}
+	}
+
+	params := url.Values{}
+	if filter != "" {
+		params.Set("Filter", filter)
+	}
+	sortBy := r.opts.Field
+	if r.opts.KeyField != "" {
+		sortBy += "," + r.opts.KeyField
+	}
+	params.Set("Sort", sortBy)
+
+	batchsize := r.opts.Batchsize
+	if batchsize == 0 {
+		batchsize = r.client.option.Batchsize
+	}
+
+	batch := &DeltaBatch{Since: since, Watermark: watermark, reader: r}
+	records := []map[string]interface{}{}
+	next := cursor
+	// Entries delivered at the cursor time come first; skip them page by page
+	for offset := 0; ; {
+		page, total, err := r.client.getPage(WithoutCache(ctx), r.endpoint, params, batchsize, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset += len(page)
+		batch.More = len(page) > 0 && offset < total
+
+		for _, record := range page {
+			key := r.recordKey(record)
+			// Without a valid modification time the watermark could never move forward
+			value, ok := record[r.opts.Field].(string)
+			if !ok {
+				return nil, &PxError{Endpoint: r.endpoint, Message: fmt.Sprintf("Entry %s has no modification field %s", key, r.opts.Field)}
+			}
+			modified, err := pxtime.Parse(value)
+			if err != nil {
+				return nil, &PxError{Endpoint: r.endpoint, Message: fmt.Sprintf("Invalid %s of entry %s: %s", r.opts.Field, key, err)}
+			}
+			if cursor != nil && modified.Equal(cursor.modified) && cursor.keys[key] {
+				continue
+			}
+			records = append(records, record)
+
+			if next == nil || !modified.Equal(next.modified) {
+				next = &deltaCursor{modified: modified, keys: map[string]bool{}}
+			} else if next == cursor {
+				next = &deltaCursor{modified: modified, keys: copyKeys(cursor.keys)}
+			}
+			next.keys[key] = true
+			if modified.After(batch.Watermark) {
+				batch.Watermark = modified
+			}
+		}
+		if len(records) > 0 || !batch.More {
+			break
+		}
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return nil, &PxError{Endpoint: r.endpoint, Message: fmt.Sprintf("JSON Encoding failed: %s", err)}
+	}
+	batch.Data = data
+	batch.Count = len(records)
+	batch.cursor = next
+	return batch, nil
+}
+
+// recordKey identifies an entry for the cursor; without KeyField the whole entry is used
+func (r *DeltaReader) recordKey(record map[string]interface{}) string {
+	if r.opts.KeyField != "" {
+		return itemKey(record, r.opts.KeyField)
+	}
+	data, _ := json.Marshal(record)
+	return string(data)
+}
+
+// copyKeys returns a copy of keys
+func copyKeys(keys map[string]bool) map[string]bool {
+	copied := make(map[string]bool, len(keys))
+	for key := range keys {
+		copied[key] = true
+	}
+	return copied
+}
+
+// Decode unmarshals the entries into v, e.g. a slice of a model
+func (b *DeltaBatch) Decode(v interface{}) error {
+	if err := json.Unmarshal(b.Data, v); err != nil {
+		return &PxError{Endpoint: b.reader.endpoint, Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+	}
+	return nil
+}
+
+// Commit confirms the batch and saves its watermark. The watermark never moves backwards.
+// If More is set, the next Next continues after the last entry of the batch.
+func (b *DeltaBatch) Commit() error {
+	if err := b.saveWatermark(); err != nil {
+		return err
+	}
+	b.reader.mu.Lock()
+	defer b.reader.mu.Unlock()
+	if b.More {
+		b.reader.cursor = b.cursor
+	} else {
+		b.reader.cursor = nil
+	}
+	return nil
+}
+
+// saveWatermark saves the watermark of the batch unless the stored one is later
+func (b *DeltaBatch) saveWatermark() error {
+	if b.Watermark.IsZero() {
+		return nil
+	}
+	current, ok, err := b.reader.store.Load(b.reader.opts.Key)
+	if err != nil {
+		return err
+	}
+	if ok && !b.Watermark.After(current) {
+		return nil
+	}
+	return b.reader.store.Save(b.reader.opts.Key, b.Watermark)
+}
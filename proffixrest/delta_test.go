@@ -0,0 +1,210 @@
+package proffixrest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+func deltaFake(t *testing.T) *fakePX {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "GeaendertAm": "2024-03-01 10:00:00"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A2", "GeaendertAm": "2024-03-02 09:30:00"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A3", "GeaendertAm": "2024-03-01 12:00:00"})
+	return px
+}
+
+func TestDeltaReader(t *testing.T) {
+	ctx := context.Background()
+	px := deltaFake(t)
+	store := NewMemoryWatermarkStore()
+	reader := px.client(&Options{Batchsize: 2}).NewDeltaReader("LAG/Artikel", store, &DeltaOptions{KeyField: "ArtikelNr", Overlap: 5 * time.Minute})
+
+	// First pull reads everything in modification order, one page of Batchsize at a time
+	batch, err := reader.Next(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var items []struct{ ArtikelNr string }
+	if err := batch.Decode(&items); err != nil {
+		t.Fatalf("Expected no error on Decode, got %v", err)
+	}
+	if batch.Count != 2 || !batch.More || items[0].ArtikelNr != "A1" || items[1].ArtikelNr != "A3" {
+		t.Errorf("Expected A1, A3 and more, got %+v (%v)", items, batch.More)
+	}
+	if pxtime.Format(batch.Watermark) != "2024-03-01 12:00:00" {
+		t.Errorf("Expected watermark of A3, got %v", batch.Watermark)
+	}
+
+	// Without commit the same entries are returned again
+	again, _ := reader.Next(ctx)
+	if again.Count != 2 || string(again.Data) != string(batch.Data) {
+		t.Errorf("Expected uncommitted batch again, got %s", again.Data)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Expected no error on Commit, got %v", err)
+	}
+	if watermark, _, _ := store.Load("LAG/Artikel"); pxtime.Format(watermark) != "2024-03-01 12:00:00" {
+		t.Errorf("Expected watermark saved per page, got %v", watermark)
+	}
+
+	// The next page continues after A3 without the overlap
+	batch, _ = reader.Next(ctx)
+	if batch.Count != 1 || batch.More || pxtime.Format(batch.Watermark) != "2024-03-02 09:30:00" {
+		t.Errorf("Expected only A2 as last page, got %s (%v)", batch.Data, batch.More)
+	}
+	_ = batch.Commit()
+
+	// A new pass reads the changes since the watermark, including the overlap window
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "GeaendertAm": "2024-03-02 09:27:00"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A4", "GeaendertAm": "2024-03-03 08:00:00"})
+
+	var keys []string
+	for more := true; more; {
+		batch, err = reader.Next(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if pxtime.Format(batch.Since) != "2024-03-02 09:25:00" && len(keys) == 0 {
+			t.Errorf("Expected Since with overlap, got %v", batch.Since)
+		}
+		items = nil
+		_ = batch.Decode(&items)
+		for _, item := range items {
+			keys = append(keys, item.ArtikelNr)
+		}
+		more = batch.More
+		_ = batch.Commit()
+	}
+	if strings.Join(keys, ",") != "A1,A2,A4" {
+		t.Errorf("Expected A1 (overlap), A2 (overlap) and A4, got %v", keys)
+	}
+
+	// Nothing changed: only the entries within the overlap are returned
+	batch, _ = reader.Next(ctx)
+	if batch.Count != 1 || batch.More {
+		t.Errorf("Expected only A4 within the overlap, got %s", batch.Data)
+	}
+	watermark, _, _ := store.Load("LAG/Artikel")
+	if pxtime.Format(watermark) != "2024-03-03 08:00:00" {
+		t.Errorf("Expected watermark of A4, got %v", watermark)
+	}
+}
+
+func TestDeltaReader_SameTimestampAcrossPages(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	for _, nr := range []string{"B1", "B2", "B3", "B4", "B5"} {
+		px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": nr, "GeaendertAm": "2024-03-01 10:00:00"})
+	}
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "B0", "GeaendertAm": "2024-03-01 11:00:00"})
+	reader := px.client(&Options{Batchsize: 2}).NewDeltaReader("LAG/Artikel", NewMemoryWatermarkStore(), &DeltaOptions{KeyField: "ArtikelNr"})
+
+	var keys []string
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("Expected paging to end, got %v", keys)
+		}
+		batch, err := reader.Next(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if batch.Count > 2 {
+			t.Errorf("Expected at most 2 entries per page, got %d", batch.Count)
+		}
+		var items []struct{ ArtikelNr string }
+		_ = batch.Decode(&items)
+		for _, item := range items {
+			keys = append(keys, item.ArtikelNr)
+		}
+		if err := batch.Commit(); err != nil {
+			t.Fatalf("Expected no error on Commit, got %v", err)
+		}
+		if !batch.More {
+			break
+		}
+	}
+	if strings.Join(keys, ",") != "B1,B2,B3,B4,B5,B0" {
+		t.Errorf("Expected every entry exactly once, got %v", keys)
+	}
+}
+
+func TestDeltaReader_InvalidModificationField(t *testing.T) {
+	ctx := context.Background()
+	px := deltaFake(t)
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A9", "GeaendertAm": nil})
+	reader := px.client(nil).NewDeltaReader("LAG/Artikel", NewMemoryWatermarkStore(), &DeltaOptions{KeyField: "ArtikelNr"})
+
+	_, err := reader.Next(ctx)
+	if err == nil || !strings.Contains(err.Error(), "A9") || !strings.Contains(err.Error(), "GeaendertAm") {
+		t.Errorf("Expected error naming entry A9 and GeaendertAm, got %v", err)
+	}
+
+	// A misspelled field fails as well instead of re-reading everything
+	reader = px.client(nil).NewDeltaReader("LAG/Artikel", NewMemoryWatermarkStore(), &DeltaOptions{Field: "GeandertAm", KeyField: "ArtikelNr"})
+	if _, err := reader.Next(ctx); err == nil {
+		t.Errorf("Expected error for a missing modification field")
+	}
+
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A9", "GeaendertAm": "01.03.2024"})
+	reader = px.client(nil).NewDeltaReader("LAG/Artikel", NewMemoryWatermarkStore(), &DeltaOptions{KeyField: "ArtikelNr"})
+	if _, err := reader.Next(ctx); err == nil || !strings.Contains(err.Error(), "Invalid GeaendertAm of entry A9") {
+		t.Errorf("Expected error for an invalid timestamp")
+	}
+}
+
+func TestDeltaBatch_CommitNeverMovesBack(t *testing.T) {
+	ctx := context.Background()
+	px := deltaFake(t)
+	store := NewMemoryWatermarkStore()
+	reader := px.client(nil).NewDeltaReader("LAG/Artikel", store, &DeltaOptions{Key: "artikel", Filter: "ArtikelNr=='A1'"})
+
+	old, _ := reader.Next(ctx)
+	future := time.Date(2030, 1, 1, 0, 0, 0, 0, pxtime.Location())
+	_ = store.Save("artikel", future)
+
+	if err := old.Commit(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if watermark, _, _ := store.Load("artikel"); !watermark.Equal(future) {
+		t.Errorf("Expected watermark not to move backwards, got %v", watermark)
+	}
+
+	empty, _ := reader.Next(ctx)
+	if empty.Count != 0 || string(empty.Data) != "[]" {
+		t.Errorf("Expected empty batch, got %s", empty.Data)
+	}
+}
+
+func TestFileWatermarkStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watermarks.json")
+	store := NewFileWatermarkStore(path)
+
+	if _, ok, err := store.Load("LAG/Artikel"); ok || err != nil {
+		t.Errorf("Expected no watermark, got %v (%v)", ok, err)
+	}
+
+	watermark := time.Date(2024, 3, 2, 9, 30, 0, 0, pxtime.Location())
+	if err := store.Save("LAG/Artikel", watermark); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = store.Save("ADR/Adresse", watermark.Add(time.Hour))
+
+	// A new store reads the persisted file
+	got, ok, err := NewFileWatermarkStore(path).Load("LAG/Artikel")
+	if err != nil || !ok || !got.Equal(watermark) {
+		t.Errorf("Expected %v, got %v (%v, %v)", watermark, got, ok, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Load("LAG/Artikel"); err == nil {
+		t.Errorf("Expected error for a corrupt file")
+	}
+}
@@ -0,0 +1,219 @@
+package proffixrest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// requestHeaderKey is the context key of additional request headers
+type requestHeaderKey struct{}
+
+// withRequestHeader returns a context whose requests send the additional header
+func withRequestHeader(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, requestHeaderKey{}, header)
+}
+
+// DownloadOptions configures DownloadFile and DownloadList
+type DownloadOptions struct {
+	Params url.Values  // Query of the download, e.g. for PRO/Datei
+	SHA256 string      // Expected hex SHA-256 of the file. If set and different, the download fails
+	Resume bool        // Keeps the .part file of a failed download and continues it with a Range request
+	Perm   os.FileMode // Permissions of the file. Default is 0644
+}
+
+// DownloadResult describes a completed download
+type DownloadResult struct {
+	Path        string // Final path of the file
+	FileName    string // Filename sent by PROFFIX (Content-Disposition)
+	ContentType string
+	Size        int64
+	SHA256      string // Hex SHA-256 of the file
+	Resumed     bool   // Download continued an existing .part file
+}
+
+// DownloadFile downloads a file of PRO/Datei to path.
+// The data is streamed to path + ".part" in the same directory, checked against Content-Length and
+// the optional SHA-256 and renamed to path only if it is complete.
+func (c *Client) DownloadFile(ctx context.Context, dateinr string, path string, opts *DownloadOptions) (*DownloadResult, error) {
+	var o DownloadOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Perm == 0 {
+		o.Perm = 0o644
+	}
+
+	endpoint := "PRO/Datei/" + dateinr
+	path = filepath.Clean(path)
+	part := path + ".part"
+
+	res, err := c.download(ctx, endpoint, part, o)
+	if err != nil {
+		// Keep the partial data for the next attempt unless it is corrupt
+		var pxErr *PxError
+		if !o.Resume || (errors.As(err, &pxErr) && pxErr.Type == "CHECKSUM_MISMATCH") {
+			_ = os.Remove(part)
+		}
+		return nil, err
+	}
+
+	if err := os.Chmod(part, o.Perm); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(part, path); err != nil {
+		return nil, err
+	}
+	res.Path = path
+
+	logDebug(ctx, c, fmt.Sprintf("Downloaded %s to %s (%d bytes, SHA-256 %s)", endpoint, path, res.Size, res.SHA256))
+	return res, nil
+}
+
+// DownloadList generates a list and downloads its file to path like DownloadFile.
+func (c *Client) DownloadList(ctx context.Context, listenr int, body interface{}, path string, opts *DownloadOptions) (*DownloadResult, error) {
+	dateiNr, resp, _, status, err := c.generateList(ctx, listenr, body)
+	closeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusCreated || dateiNr == "" {
+		return nil, &PxError{Endpoint: "PRO/Liste/" + strconv.Itoa(listenr) + "/generieren", Status: status, Message: "List generation returned no file"}
+	}
+	return c.DownloadFile(ctx, dateiNr, path, opts)
+}
+
+// download streams the file to part and returns its size and hash
+func (c *Client) download(ctx context.Context, endpoint string, part string, o DownloadOptions) (*DownloadResult, error) {
+	res := &DownloadResult{}
+	hasher := sha256.New()
+
+	// Continue an existing part file; its data is hashed first
+	var offset int64
+	if o.Resume {
+		var err error
+		if offset, err = hashFile(part, hasher); err != nil {
+			return nil, err
+		}
+	}
+
+	rc, header, status, err := c.rangeGet(ctx, endpoint, o.Params, offset)
+	if err != nil && offset > 0 && status == http.StatusRequestedRangeNotSatisfiable {
+		// The part file does not match the file anymore, start over
+		offset = 0
+		hasher.Reset()
+		rc, header, status, err = c.rangeGet(ctx, endpoint, o.Params, 0)
+	}
+	if err != nil {
+		closeBody(rc)
+		return nil, err
+	}
+	defer closeBody(rc)
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 && status == http.StatusPartialContent {
+		start, _, ok := parseContentRange(header.Get("Content-Range"))
+		if !ok || start != offset {
+			return nil, &PxError{Endpoint: endpoint, Type: "INCOMPLETE_DOWNLOAD", Message: fmt.Sprintf("Unexpected Content-Range %q for offset %d", header.Get("Content-Range"), offset)}
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		res.Resumed = true
+	} else if offset > 0 {
+		// The server ignored the Range header and sends the whole file
+		offset = 0
+		hasher.Reset()
+	}
+
+	out, err := os.OpenFile(part, flags, 0o600) // #nosec G304 -- caller controls destination path by design
+	if err != nil {
+		return nil, err
+	}
+	written, err := io.Copy(io.MultiWriter(out, hasher), rc)
+	if err != nil {
+		_ = out.Close()
+		return nil, &PxError{Endpoint: endpoint, Type: "INCOMPLETE_DOWNLOAD", Message: fmt.Sprintf("Download interrupted after %d bytes: %s", offset+written, err)}
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+
+	res.Size = offset + written
+	res.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	res.FileName = fileNameFromHeader(header)
+	res.ContentType = header.Get("Content-Type")
+
+	// Compare with the expected length of the whole file
+	expected := int64(-1)
+	if res.Resumed {
+		_, expected, _ = parseContentRange(header.Get("Content-Range"))
+	} else if length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		expected = length
+	}
+	if expected >= 0 && res.Size != expected {
+		return nil, &PxError{Endpoint: endpoint, Type: "INCOMPLETE_DOWNLOAD", Message: fmt.Sprintf("Downloaded %d of %d bytes", res.Size, expected)}
+	}
+
+	if o.SHA256 != "" && !strings.EqualFold(o.SHA256, res.SHA256) {
+		return nil, &PxError{Endpoint: endpoint, Type: "CHECKSUM_MISMATCH", Message: fmt.Sprintf("SHA-256 is %s, expected %s", res.SHA256, o.SHA256)}
+	}
+	return res, nil
+}
+
+// rangeGet sends a GET request starting at offset. The cache and deduplication are bypassed.
+func (c *Client) rangeGet(ctx context.Context, endpoint string, params url.Values, offset int64) (io.ReadCloser, http.Header, int, error) {
+	if offset > 0 {
+		ctx = withRequestHeader(ctx, http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}})
+	}
+	return c.get(ctx, endpoint, params)
+}
+
+// hashFile writes the content of an existing file to h and returns its size; a missing file has size 0
+func hashFile(path string, h hash.Hash) (int64, error) {
+	file, err := os.Open(path) // #nosec G304 -- caller controls destination path by design
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+	return io.Copy(h, file)
+}
+
+// parseContentRange parses "bytes start-end/total"; total is -1 if unknown
+func parseContentRange(value string) (start int64, total int64, ok bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "bytes ")
+	rangePart, totalPart, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if totalPart != "*" {
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
@@ -0,0 +1,205 @@
+package proffixrest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fileFake serves PRO/Datei/1 with Range support. While cut is > 0 the response is aborted after cut bytes.
+type fileFake struct {
+	*fakePX
+	data []byte
+
+	mu          sync.Mutex
+	cut         int
+	ignoreRange bool
+	ranges      []string
+}
+
+func newFileFake(t *testing.T, data []byte) *fileFake {
+	t.Helper()
+	f := &fileFake{fakePX: newFakePX(t, nil), data: data}
+	f.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		switch {
+		case r.Method == http.MethodPost && endpoint == "PRO/Liste/5/generieren":
+			w.Header().Set("Location", f.server.URL+"/pxapi/v4/PRO/Datei/1")
+			w.WriteHeader(http.StatusCreated)
+			return true
+		case r.Method != http.MethodGet || endpoint != "PRO/Datei/1":
+			return false
+		}
+
+		f.mu.Lock()
+		cut, ignoreRange := f.cut, f.ignoreRange
+		f.cut = 0
+		f.ranges = append(f.ranges, r.Header.Get("Range"))
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="Liste.pdf"`)
+		if cut > 0 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(f.data)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(f.data[:cut])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if ignoreRange {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(f.data))
+		return true
+	}
+	return f
+}
+
+// set configures the next responses
+func (f *fileFake) set(cut int, ignoreRange bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cut, f.ignoreRange = cut, ignoreRange
+}
+
+// rangeHeader returns the Range header of the i-th request
+func (f *fileFake) rangeHeader(i int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ranges[i]
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadFile(t *testing.T) {
+	data := []byte(strings.Repeat("PROFFIX-", 1000))
+	px := newFileFake(t, data)
+	c := px.client(nil)
+	path := filepath.Join(t.TempDir(), "liste.pdf")
+
+	res, err := c.DownloadFile(context.Background(), "1", path, &DownloadOptions{SHA256: strings.ToUpper(sha256Hex(data))})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Size != int64(len(data)) || res.SHA256 != sha256Hex(data) || res.Resumed {
+		t.Errorf("Expected size %d and hash, got %+v", len(data), res)
+	}
+	if res.FileName != "Liste.pdf" || res.ContentType != "application/pdf" || res.Path != path {
+		t.Errorf("Expected file metadata, got %+v", res)
+	}
+	content, _ := os.ReadFile(path)
+	if !bytes.Equal(content, data) {
+		t.Errorf("Expected downloaded content")
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected no .part file after success")
+	}
+
+	// DownloadList generates the list and downloads its file
+	listPath := filepath.Join(t.TempDir(), "list.pdf")
+	if res, err := c.DownloadList(context.Background(), 5, nil, listPath, nil); err != nil || res.Size != int64(len(data)) {
+		t.Errorf("Expected list download, got %+v %v", res, err)
+	}
+}
+
+func TestDownloadFile_Failures(t *testing.T) {
+	data := []byte(strings.Repeat("x", 4096))
+	px := newFileFake(t, data)
+	c := px.client(nil)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "liste.pdf")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err := c.DownloadFile(context.Background(), "1", path, &DownloadOptions{SHA256: sha256Hex([]byte("other"))})
+	if pxErr, ok := err.(*PxError); !ok || pxErr.Type != "CHECKSUM_MISMATCH" {
+		t.Errorf("Expected CHECKSUM_MISMATCH, got %v", err)
+	}
+
+	px.set(100, false)
+	_, err = c.DownloadFile(context.Background(), "1", path, nil)
+	if pxErr, ok := err.(*PxError); !ok || pxErr.Type != "INCOMPLETE_DOWNLOAD" {
+		t.Errorf("Expected INCOMPLETE_DOWNLOAD, got %v", err)
+	}
+
+	// The existing file is untouched and no partial data is left without Resume
+	content, _ := os.ReadFile(path)
+	if string(content) != "old" {
+		t.Errorf("Expected existing file to be kept, got %d bytes", len(content))
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the existing file, got %d entries", len(entries))
+	}
+}
+
+func TestDownloadFile_Resume(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 500))
+	px := newFileFake(t, data)
+	c := px.client(nil)
+	path := filepath.Join(t.TempDir(), "liste.pdf")
+	opts := &DownloadOptions{Resume: true, SHA256: sha256Hex(data)}
+
+	px.set(1000, false)
+	if _, err := c.DownloadFile(context.Background(), "1", path, opts); err == nil {
+		t.Fatalf("Expected interrupted download")
+	}
+	if info, err := os.Stat(path + ".part"); err != nil || info.Size() != 1000 {
+		t.Fatalf("Expected .part file with 1000 bytes, got %v", err)
+	}
+
+	res, err := c.DownloadFile(context.Background(), "1", path, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !res.Resumed || res.Size != int64(len(data)) || res.SHA256 != sha256Hex(data) {
+		t.Errorf("Expected resumed download of the whole file, got %+v", res)
+	}
+	if got := px.rangeHeader(1); got != "bytes=1000-" {
+		t.Errorf("Expected Range bytes=1000-, got %q", got)
+	}
+	content, _ := os.ReadFile(path)
+	if !bytes.Equal(content, data) {
+		t.Errorf("Expected complete content after resume")
+	}
+
+	// A server ignoring the Range header sends the whole file again
+	px.set(1000, false)
+	_, _ = c.DownloadFile(context.Background(), "1", path, opts)
+	px.set(0, true)
+	res, err = c.DownloadFile(context.Background(), "1", path, opts)
+	if err != nil || res.Resumed || res.Size != int64(len(data)) {
+		t.Errorf("Expected full download when the range is ignored, got %+v %v", res, err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-9/*", 0, -1, true},
+		{"bytes */200", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if start != tt.start || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q): expected %d %d %v, got %d %d %v", tt.value, tt.start, tt.total, tt.ok, start, total, ok)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package proffixrest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -14,6 +15,8 @@ type PxError struct {
 	Type     string           `json:"Type"`
 	Message  string           `json:"Message"`
 	Fields   []PxInvalidField `json:"Fields"`
+
+	cause error // Underlying error of a failed request, e.g. a timeout
 }
 
 // PxInvalidField defines the Error struct of Fields Error of PROFFIX REST-API
@@ -48,6 +51,11 @@ func (e *PxError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying error, so errors.Is works with e.g. context.DeadlineExceeded
+func (e *PxError) Unwrap() error {
+	return e.cause
+}
+
 // NewPxError creates a new PxError
 func NewPxError(rc io.Reader, status int, endpoint string) *PxError {
 
@@ -76,3 +84,15 @@ func NewPxError(rc io.Reader, status int, endpoint string) *PxError {
 
 	return &pxerr
 }
+
+// toPxError returns err as *PxError; other errors (e.g. transport or context errors) are wrapped
+func toPxError(err error, endpoint string) *PxError {
+	var pxErr *PxError
+	if errors.As(err, &pxErr) {
+		return pxErr
+	}
+	if err == nil {
+		return &PxError{Endpoint: endpoint, Message: fmt.Sprintf("request to %s failed", endpoint)}
+	}
+	return &PxError{Endpoint: endpoint, Message: err.Error()}
+}
@@ -1,6 +1,8 @@
 package proffixrest
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -161,3 +163,24 @@ func TestNewPxError_NoStatusNoEndpoint(t *testing.T) {
 		t.Errorf("Expected 'unknown error' message, got '%s'", err.Message)
 	}
 }
+
+func TestToPxError(t *testing.T) {
+	pxErr := &PxError{Type: "NOT_FOUND", Message: "not found"}
+	if got := toPxError(pxErr, "ADR/Adresse"); got != pxErr {
+		t.Errorf("Expected the same PxError, got %v", got)
+	}
+
+	// Wrapped PxError is unwrapped
+	if got := toPxError(fmt.Errorf("sync: %w", pxErr), "ADR/Adresse"); got != pxErr {
+		t.Errorf("Expected the wrapped PxError, got %v", got)
+	}
+
+	got := toPxError(context.DeadlineExceeded, "ADR/Adresse")
+	if got.Endpoint != "ADR/Adresse" || got.Message != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected wrapped context error, got %+v", got)
+	}
+
+	if got := toPxError(nil, "ADR/Adresse"); got == nil || got.Message == "" {
+		t.Errorf("Expected error with message for nil, got %+v", got)
+	}
+}
@@ -0,0 +1,269 @@
+package proffixrest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ExportOptions configures ExportFiles
+type ExportOptions struct {
+	Query       url.Values // Query of the endpoint, e.g. Filter. Fields is set by ExportFiles
+	KeyField    string     // Key of the endpoint, used as directory of the files. Required
+	FileFields  []string   // Fields referencing a PRO/Datei entry. Default is DateiNr
+	Concurrency int        // Number of downloads in parallel. Default is 1
+	Manifest    string     // Format of the manifest: "json" (default), "csv" or "none"
+}
+
+// ExportItem is the result for a single file of ExportFiles
+type ExportItem struct {
+	Key         string   `json:"Key"`
+	Field       string   `json:"Field"`
+	DateiNr     string   `json:"DateiNr"`
+	Path        string   `json:"Path,omitempty"` // Path relative to the export directory, with forward slashes
+	FileName    string   `json:"FileName,omitempty"`
+	ContentType string   `json:"ContentType,omitempty"`
+	Size        int64    `json:"Size"`
+	SHA256      string   `json:"SHA256,omitempty"`
+	Error       *PxError `json:"Error,omitempty"` // Error if the download failed
+}
+
+// ExportResult is the result of ExportFiles in the order of the entries
+type ExportResult struct {
+	Total     int          `json:"Total"` // Number of referenced files
+	Succeeded int          `json:"Succeeded"`
+	Failed    int          `json:"Failed"`
+	Manifest  string       `json:"Manifest,omitempty"` // Path of the manifest
+	Items     []ExportItem `json:"Items"`
+}
+
+// ExportFiles downloads all files referenced by the entries of endpoint matching opts.Query into dir.
+// Every file is saved as <dir>/<key>/<filename> with SaveFileToDir; keys which are no valid directory name
+// (e.g. "A/1") get a short hash of the key appended, so different keys never share a directory.
+// A rerun reuses the files listed in the manifest of a previous export: unchanged files are kept and changed
+// files are replaced, instead of adding " (1)" copies. Failed downloads are reported in the result and the manifest.
+func (c *Client) ExportFiles(ctx context.Context, endpoint string, dir string, opts *ExportOptions) (*ExportResult, error) {
+	var o ExportOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.KeyField == "" {
+		return nil, &PxError{Endpoint: endpoint, Message: "ExportFiles needs a KeyField"}
+	}
+	if len(o.FileFields) == 0 {
+		o.FileFields = []string{"DateiNr"}
+	}
+	switch o.Manifest {
+	case "":
+		o.Manifest = "json"
+	case "json", "csv", "none":
+	default:
+		return nil, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("Unknown manifest format %q", o.Manifest)}
+	}
+
+	items, err := c.exportItems(ctx, endpoint, o)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	previous := map[string]ExportItem{}
+	for _, format := range []string{"csv", "json"} {
+		for id, item := range readManifest(filepath.Join(dir, "manifest."+format), format) {
+			previous[id] = item
+		}
+	}
+
+	res := &ExportResult{Total: len(items), Items: items}
+	parallelN(o.Concurrency, len(items), func(i int) {
+		item := &res.Items[i]
+		fileEndpoint := "PRO/Datei/" + item.DateiNr
+		keyDir := filepath.Join(dir, exportKeyDir(item.Key))
+		if err := os.MkdirAll(keyDir, 0o750); err != nil {
+			item.Error = toPxError(err, fileEndpoint)
+			return
+		}
+		saved, err := c.SaveFileToDir(ctx, keyDir, item.DateiNr)
+		if err != nil {
+			item.Error = toPxError(err, fileEndpoint)
+			return
+		}
+		if prev, ok := previous[exportID(*item)]; ok {
+			if err := reuseExport(keyDir, prev, saved); err != nil {
+				item.Error = toPxError(err, fileEndpoint)
+				return
+			}
+		}
+		item.Path = filepath.ToSlash(filepath.Join(filepath.Base(keyDir), filepath.Base(saved.Path)))
+		item.FileName = saved.FileName
+		item.ContentType = saved.ContentType
+		item.Size = saved.Size
+		item.SHA256 = saved.SHA256
+	})
+
+	for _, item := range res.Items {
+		if item.Error != nil {
+			res.Failed++
+		} else {
+			res.Succeeded++
+		}
+	}
+
+	if o.Manifest != "none" {
+		res.Manifest = filepath.Join(dir, "manifest."+o.Manifest)
+		if err := writeManifest(res.Manifest, o.Manifest, res); err != nil {
+			return res, err
+		}
+	}
+
+	logDebug(ctx, c, fmt.Sprintf("Exported %d of %d files of %s to %s", res.Succeeded, res.Total, endpoint, dir))
+	return res, nil
+}
+
+// exportItems returns an item for every file referenced by the matching entries
+func (c *Client) exportItems(ctx context.Context, endpoint string, o ExportOptions) ([]ExportItem, error) {
+	params := url.Values{}
+	for k, v := range o.Query {
+		params[k] = append([]string(nil), v...)
+	}
+	params.Set("Fields", strings.Join(append([]string{o.KeyField}, o.FileFields...), ","))
+
+	// A partial read would report an incomplete export as complete
+	records, err := c.getAll(WithoutCache(ctx), endpoint, o.KeyField, params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ExportItem
+	for _, record := range records {
+		key := itemKey(record, o.KeyField)
+		if key == "" {
+			continue
+		}
+		for _, field := range o.FileFields {
+			// Entries without file (null or empty) are not exported
+			if dateiNr := fileRef(record[field]); dateiNr != "" {
+				items = append(items, ExportItem{Key: key, Field: field, DateiNr: dateiNr})
+			}
+		}
+	}
+	return items, nil
+}
+
+// exportKeyDir returns the directory name of key. If the key had to be sanitized, a short hash of the
+// raw key is appended, so keys like "A/1" and "A_1" do not collide.
+func exportKeyDir(key string) string {
+	name := SanitizeFileName(strings.NewReplacer("/", "_", `\`, "_").Replace(key), "")
+	if name == key {
+		return name
+	}
+	sum := sha256.Sum256([]byte(key))
+	return name + "_" + hex.EncodeToString(sum[:4])
+}
+
+// exportID identifies the file of an item across exports
+func exportID(item ExportItem) string {
+	return item.Key + "\x00" + item.Field + "\x00" + item.DateiNr
+}
+
+// reuseExport moves the downloaded file to the path of the previous export of the same file. If the previous
+// file is unchanged since that export, it is kept for the same content and replaced otherwise. Files modified
+// or removed since the previous export are not touched.
+func reuseExport(keyDir string, prev ExportItem, saved *DownloadResult) error {
+	prevPath := filepath.Join(filepath.Dir(keyDir), filepath.FromSlash(prev.Path))
+	if prev.Error != nil || prev.SHA256 == "" || filepath.Dir(prevPath) != keyDir || prevPath == saved.Path {
+		return nil
+	}
+
+	h := sha256.New()
+	if _, err := hashFile(prevPath, h); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != prev.SHA256 {
+		return nil
+	}
+	if saved.SHA256 == prev.SHA256 {
+		if err := os.Remove(saved.Path); err != nil {
+			return err
+		}
+	} else if err := os.Rename(saved.Path, prevPath); err != nil {
+		return err
+	}
+	saved.Path = prevPath
+	return nil
+}
+
+// readManifest returns the successful items of an existing manifest by exportID
+func readManifest(path string, format string) map[string]ExportItem {
+	data, err := os.ReadFile(path) // #nosec G304 -- manifest of the export directory
+	if err != nil {
+		return nil
+	}
+
+	var items []ExportItem
+	if format == "json" {
+		var res ExportResult
+		if json.Unmarshal(data, &res) != nil {
+			return nil
+		}
+		items = res.Items
+	} else {
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil
+		}
+		for _, row := range rows {
+			if len(row) < 9 || row[0] == "Key" || row[8] != "" {
+				continue
+			}
+			items = append(items, ExportItem{Key: row[0], Field: row[1], DateiNr: row[2], Path: row[3], SHA256: row[7]})
+		}
+	}
+
+	previous := make(map[string]ExportItem, len(items))
+	for _, item := range items {
+		if item.Error == nil && item.Path != "" {
+			previous[exportID(item)] = item
+		}
+	}
+	return previous
+}
+
+// writeManifest writes the items of res as JSON or CSV to path
+func writeManifest(path string, format string, res *ExportResult) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0o644)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"Key", "Field", "DateiNr", "Path", "FileName", "ContentType", "Size", "SHA256", "Error"})
+	for _, item := range res.Items {
+		var errMsg string
+		if item.Error != nil {
+			errMsg = item.Error.Error()
+		}
+		_ = w.Write([]string{item.Key, item.Field, item.DateiNr, item.Path, item.FileName, item.ContentType, strconv.FormatInt(item.Size, 10), item.SHA256, errMsg})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,196 @@
+package proffixrest
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newExportFake serves ADR/Adresse with file references and PRO/Datei/a, PRO/Datei/b
+func newExportFake(t *testing.T) *fakePX {
+	t.Helper()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 1, "DateiNr": "a", "Anhang": map[string]interface{}{"DateiNr": "b"}})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 2, "DateiNr": nil, "Anhang": nil})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 3, "DateiNr": "fehlt"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if !strings.HasPrefix(endpoint, "PRO/Datei/") {
+			return false
+		}
+		switch strings.TrimPrefix(endpoint, "PRO/Datei/") {
+		case "a":
+			w.Header().Set("Content-Disposition", `attachment; filename="Vertrag.pdf"`)
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("vertrag"))
+		case "b":
+			w.Header().Set("Content-Disposition", `attachment; filename="../Foto.jpg"`)
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write([]byte("foto"))
+		default:
+			writePxError(w, http.StatusNotFound, "NOT_FOUND", "Datei nicht gefunden")
+		}
+		return true
+	}
+	return px
+}
+
+func TestClient_ExportFiles(t *testing.T) {
+	px := newExportFake(t)
+	c := px.client(nil)
+	dir := t.TempDir()
+
+	res, err := c.ExportFiles(context.Background(), "ADR/Adresse", dir, &ExportOptions{
+		KeyField:    "AdressNr",
+		FileFields:  []string{"DateiNr", "Anhang"},
+		Concurrency: 3,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Total != 3 || res.Succeeded != 2 || res.Failed != 1 {
+		t.Errorf("Expected 3 files with 2 succeeded and 1 failed, got %+v", res)
+	}
+
+	for _, path := range []string{"1/Vertrag.pdf", "1/Foto.jpg"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected file %s, got %v", path, err)
+		}
+	}
+	for _, item := range res.Items {
+		if item.DateiNr == "fehlt" && (item.Error == nil || item.Error.Status != http.StatusNotFound || item.Key != "3") {
+			t.Errorf("Expected 404 error for entry 3, got %+v", item)
+		}
+		if item.DateiNr == "b" && item.Path != "1/Foto.jpg" {
+			t.Errorf("Expected relative path 1/Foto.jpg, got %s", item.Path)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Expected manifest, got %v", err)
+	}
+	var manifest ExportResult
+	if err := json.Unmarshal(data, &manifest); err != nil || len(manifest.Items) != 3 {
+		t.Errorf("Expected manifest with 3 items, got %s (%v)", data, err)
+	}
+}
+
+func TestClient_ExportFiles_CSVManifest(t *testing.T) {
+	px := newExportFake(t)
+	c := px.client(nil)
+	dir := t.TempDir()
+
+	res, err := c.ExportFiles(context.Background(), "ADR/Adresse", dir, &ExportOptions{
+		KeyField: "AdressNr",
+		Query:    map[string][]string{"Filter": {"AdressNr=='1'"}},
+		Manifest: "csv",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Total != 1 || res.Manifest != filepath.Join(dir, "manifest.csv") {
+		t.Errorf("Expected 1 file and CSV manifest, got %+v", res)
+	}
+
+	file, err := os.Open(res.Manifest)
+	if err != nil {
+		t.Fatalf("Expected manifest, got %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][0] != "1" || rows[1][3] != "1/Vertrag.pdf" {
+		t.Errorf("Expected header and one row, got %v (%v)", rows, err)
+	}
+
+	if _, err := c.ExportFiles(context.Background(), "ADR/Adresse", dir, &ExportOptions{}); err == nil {
+		t.Errorf("Expected error without KeyField")
+	}
+}
+
+func TestClient_ExportFiles_Rerun(t *testing.T) {
+	px := newExportFake(t)
+	var content atomic.Value
+	content.Store("vertrag")
+	files := px.handler
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint != "PRO/Datei/a" {
+			return files(w, r, endpoint)
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="Vertrag.pdf"`)
+		_, _ = w.Write([]byte(content.Load().(string)))
+		return true
+	}
+	c := px.client(nil)
+	dir := t.TempDir()
+	opts := &ExportOptions{KeyField: "AdressNr", FileFields: []string{"DateiNr", "Anhang"}}
+
+	for _, manifest := range []string{"json", "csv"} {
+		opts.Manifest = manifest
+		for run := 0; run < 2; run++ {
+			res, err := c.ExportFiles(context.Background(), "ADR/Adresse", dir, opts)
+			if err != nil || res.Succeeded != 2 {
+				t.Fatalf("Expected 2 exported files, got %+v (%v)", res, err)
+			}
+		}
+	}
+
+	// Reruns keep the files instead of adding " (1)" copies
+	entries, _ := os.ReadDir(filepath.Join(dir, "1"))
+	if len(entries) != 2 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("Expected Vertrag.pdf and Foto.jpg only, got %v", names)
+	}
+
+	// Changed content replaces the file of the previous export
+	content.Store("vertrag v2")
+	res, err := c.ExportFiles(context.Background(), "ADR/Adresse", dir, opts)
+	if err != nil || res.Succeeded != 2 {
+		t.Fatalf("Expected 2 exported files, got %+v (%v)", res, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "1", "Vertrag.pdf")); string(data) != "vertrag v2" {
+		t.Errorf("Expected replaced content, got %q", data)
+	}
+	if entries, _ = os.ReadDir(filepath.Join(dir, "1")); len(entries) != 2 {
+		t.Errorf("Expected 2 files after a change, got %d", len(entries))
+	}
+}
+
+func TestExportKeyDir(t *testing.T) {
+	if got := exportKeyDir("1"); got != "1" {
+		t.Errorf("Expected plain key as directory, got %q", got)
+	}
+	slash, underscore := exportKeyDir("A/1"), exportKeyDir("A_1")
+	if slash == underscore || underscore != "A_1" || !strings.HasPrefix(slash, "A_1_") {
+		t.Errorf("Expected different directories for A/1 and A_1, got %q and %q", slash, underscore)
+	}
+}
+
+func TestClient_ExportFiles_IncompleteList(t *testing.T) {
+	px := newExportFake(t)
+	files := px.handler
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint == "ADR/Adresse" && strings.Contains(r.URL.Query().Get("Filter"), "AdressNr>") {
+			writePxError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "Server busy")
+			return true
+		}
+		return files(w, r, endpoint)
+	}
+	dir := t.TempDir()
+
+	res, err := px.client(&Options{Batchsize: 1}).ExportFiles(context.Background(), "ADR/Adresse", dir, &ExportOptions{KeyField: "AdressNr"})
+	if err == nil || res != nil {
+		t.Errorf("Expected error for a failed page, got %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected no manifest for an incomplete list, got %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package proffixrest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePX is a minimal in-memory PROFFIX REST-API used for offline tests
+type fakePX struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu      sync.Mutex
+	keys    map[string]string                            // collection -> keyfield
+	records map[string]map[string]map[string]interface{} // collection -> key -> record
+	nextID  int
+	calls   []string // "METHOD endpoint" of every request except login
+	logins  int      // Number of created sessions
+
+	// handler may intercept requests; returning true marks the request as handled
+	handler func(w http.ResponseWriter, r *http.Request, endpoint string) bool
+}
+
+// newFakePX starts a fake server; collections maps endpoint -> keyfield
+func newFakePX(t *testing.T, collections map[string]string) *fakePX {
+	f := &fakePX{
+		t:       t,
+		keys:    collections,
+		records: map[string]map[string]map[string]interface{}{},
+		nextID:  1000,
+	}
+	for col := range collections {
+		f.records[col] = map[string]map[string]interface{}{}
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// client returns a new Client connected to the fake server
+func (f *fakePX) client(options *Options) *Client {
+	c, err := NewClient(f.server.URL, "USR", "pw", "DEMO", []string{"VOL"}, options)
+	if err != nil {
+		f.t.Fatalf("NewClient failed: %v", err)
+	}
+	return c
+}
+
+// put stores a record directly
+func (f *fakePX) put(collection string, record map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := fmt.Sprintf("%v", record[f.keys[collection]])
+	f.records[collection][key] = record
+}
+
+// remove deletes a stored record directly
+func (f *fakePX) remove(collection, key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.records[collection], key)
+}
+
+// get returns a stored record
+func (f *fakePX) get(collection, key string) (map[string]interface{}, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rec, ok := f.records[collection][key]
+	return rec, ok
+}
+
+// count returns the number of stored records
+func (f *fakePX) count(collection string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.records[collection])
+}
+
+// loginCount returns the number of created sessions
+func (f *fakePX) loginCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins
+}
+
+// callCount counts calls with the given method and endpoint prefix
+func (f *fakePX) callCount(method, prefix string) (n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, method+" "+prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakePX) serve(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/pxapi/v4/")
+
+	if endpoint == "PRO/Login" {
+		if r.Method == http.MethodPost {
+			f.mu.Lock()
+			f.logins++
+			f.mu.Unlock()
+			w.Header().Set("pxsessionid", "session-1")
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, r.Method+" "+endpoint)
+	f.mu.Unlock()
+
+	if f.handler != nil && f.handler(w, r, endpoint) {
+		return
+	}
+
+	// Keys are split from the escaped path, so "A%2F1" is the key "A/1"
+	collection, key := f.split(strings.TrimPrefix(r.URL.EscapedPath(), "/pxapi/v4/"))
+	if collection == "" {
+		writePxError(w, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+endpoint)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := f.records[collection]
+	keyfield := f.keys[collection]
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, r, records)
+	case r.Method == http.MethodGet:
+		rec, ok := records[key]
+		if !ok {
+			writePxError(w, http.StatusNotFound, "NOT_FOUND", "not found")
+			return
+		}
+		writeJSON(w, rec)
+	case r.Method == http.MethodPost && key == "":
+		rec := decodeRecord(r.Body)
+		newKey := fmt.Sprintf("%v", rec[keyfield])
+		if rec[keyfield] == nil || newKey == "" {
+			f.nextID++
+			newKey = strconv.Itoa(f.nextID)
+			rec[keyfield] = newKey
+		}
+		if _, exists := records[newKey]; exists {
+			writePxError(w, http.StatusConflict, "CONFLICT", "exists")
+			return
+		}
+		records[newKey] = rec
+		w.Header().Set("Location", f.server.URL+"/pxapi/v4/"+collection+"/"+newKey)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut || r.Method == http.MethodPatch:
+		rec, ok := records[key]
+		if !ok {
+			writePxError(w, http.StatusNotFound, "NOT_FOUND", "not found")
+			return
+		}
+		body := decodeRecord(r.Body)
+		if r.Method == http.MethodPut {
+			rec = map[string]interface{}{keyfield: key}
+		}
+		for k, v := range body {
+			rec[k] = v
+		}
+		records[key] = rec
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && key != "":
+		if _, ok := records[key]; !ok {
+			writePxError(w, http.StatusNotFound, "NOT_FOUND", "not found")
+			return
+		}
+		delete(records, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writePxError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	}
+}
+
+// split splits an endpoint into a known collection and an optional key
+func (f *fakePX) split(endpoint string) (collection, key string) {
+	if _, ok := f.keys[endpoint]; ok {
+		return endpoint, ""
+	}
+	i := strings.LastIndex(endpoint, "/")
+	if i < 0 {
+		return "", ""
+	}
+	if _, ok := f.keys[endpoint[:i]]; ok {
+		key, err := url.PathUnescape(endpoint[i+1:])
+		if err != nil {
+			return "", ""
+		}
+		return endpoint[:i], key
+	}
+	return "", ""
+}
+
+// list serves a collection honouring Filter (Field=='value', Field>='value' or Field>'value' joined by ','), Sort, Limit and Offset
+func (f *fakePX) list(w http.ResponseWriter, r *http.Request, records map[string]map[string]interface{}) {
+	query := r.URL.Query()
+
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	items := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		if matchFilter(records[k], query.Get("Filter")) {
+			items = append(items, records[k])
+		}
+	}
+	if fields := query.Get("Sort"); fields != "" {
+		sort.SliceStable(items, func(i, j int) bool {
+			for _, field := range strings.Split(fields, ",") {
+				a, b := fmt.Sprintf("%v", items[i][field]), fmt.Sprintf("%v", items[j][field])
+				if a != b {
+					return a < b
+				}
+			}
+			return false
+		})
+	}
+	filtered := len(items)
+
+	offset, _ := strconv.Atoi(query.Get("Offset"))
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if limit, err := strconv.Atoi(query.Get("Limit")); err == nil && limit < len(items) {
+		items = items[:limit]
+	}
+
+	w.Header().Set("pxmetadata", fmt.Sprintf(`{"FilteredCount":%d}`, filtered))
+	writeJSON(w, items)
+}
+
+// matchFilter evaluates a simple PROFFIX filter (==, >= and > clauses joined by ',')
+func matchFilter(rec map[string]interface{}, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, clause := range strings.Split(filter, ",") {
+		op := ">"
+		for _, candidate := range []string{"==", ">="} {
+			if strings.Contains(clause, candidate) {
+				op = candidate
+				break
+			}
+		}
+		parts := strings.SplitN(clause, op, 2)
+		if len(parts) != 2 {
+			return false
+		}
+		want := strings.ReplaceAll(strings.Trim(parts[1], "'"), "''", "'")
+		got := fmt.Sprintf("%v", rec[parts[0]])
+		switch {
+		case op == "==" && got != want:
+			return false
+		case op != "==" && rec[parts[0]] == nil:
+			return false
+		case op == ">=" && got < want, op == ">" && got <= want:
+			return false
+		}
+	}
+	return true
+}
+
+func decodeRecord(r io.Reader) map[string]interface{} {
+	rec := map[string]interface{}{}
+	_ = json.NewDecoder(r).Decode(&rec)
+	return rec
+}
+
+func writePxError(w http.ResponseWriter, status int, typ, msg string) {
+	w.WriteHeader(status)
+	writeJSON(w, PxError{Status: status, Type: typ, Message: msg})
+}
+
+// writeJSON writes v without trailing newline like PROFFIX does
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, _ := json.Marshal(v)
+	_, _ = w.Write(b)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"mime"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -55,11 +54,9 @@ func (c *Client) GetFile(ctx context.Context, dateinr string, params url.Values)
 
 	contentType = headers.Get("Content-Type")
 	contentLength, _ = strconv.Atoi(headers.Get("Content-Length")) // Ignore error, default to 0
-	ContentDisposition := headers.Get("Content-Disposition")
-
-	_, paramsCD, errCD := mime.ParseMediaType(ContentDisposition)
-	if errCD == nil {
-		fileName = paramsCD["filename"]
+	// The name is sent by the server and must not contain directories
+	if fileName = fileNameFromHeader(headers); fileName != "" {
+		fileName = SanitizeFileName(fileName, contentType)
 	}
 
 	// If Log enabled log URL
@@ -0,0 +1,234 @@
+package proffixrest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFileNameLength is the maximum length of a sanitized file name in bytes
+const maxFileNameLength = 200
+
+// extensions are the file extensions of common content types of PROFFIX files
+var extensions = map[string]string{
+	"application/pdf":          ".pdf",
+	"application/json":         ".json",
+	"application/xml":          ".xml",
+	"application/zip":          ".zip",
+	"application/msword":       ".doc",
+	"application/vnd.ms-excel": ".xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":       ".xlsx",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"text/csv":   ".csv",
+	"text/plain": ".txt",
+	"text/xml":   ".xml",
+}
+
+// reservedNames are device names which cannot be used as file names on Windows, with any extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// fileNameFromHeader returns the filename of the Content-Disposition header.
+// filename* (RFC 5987) is preferred over filename; ISO-8859-1 names are converted to UTF-8.
+func fileNameFromHeader(headers http.Header) string {
+	value := headers.Get("Content-Disposition")
+	if _, params, err := mime.ParseMediaType(value); err == nil && params["filename"] != "" {
+		return toUTF8(params["filename"])
+	}
+
+	// Lenient parsing of unquoted names and charsets unknown to mime
+	var name, extended string
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		val = strings.Trim(strings.TrimSpace(val), `"`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "filename*":
+			extended = decodeExtValue(val)
+		case "filename":
+			name = toUTF8(val)
+		}
+	}
+	if extended != "" {
+		return extended
+	}
+	return name
+}
+
+// decodeExtValue decodes an RFC 5987 value like UTF-8'de'Gr%C3%BCsse.pdf
+func decodeExtValue(value string) string {
+	parts := strings.SplitN(value, "'", 3)
+	if len(parts) != 3 {
+		return ""
+	}
+	decoded, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(parts[0]) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		return latin1ToUTF8(decoded)
+	}
+	return toUTF8(decoded)
+}
+
+// toUTF8 returns s unchanged if it is valid UTF-8, otherwise it is read as ISO-8859-1
+func toUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return latin1ToUTF8(s)
+}
+
+// latin1ToUTF8 converts ISO-8859-1 bytes to UTF-8
+func latin1ToUTF8(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
+
+// SanitizeFileName returns name as a safe file name without directories.
+// Path separators, control and reserved characters are replaced, Windows device names (e.g. CON, LPT1) are prefixed,
+// leading dots and trailing dots or spaces are removed and the name is shortened to 200 bytes.
+// If the name has no extension, one is derived from contentType. Empty names become "datei".
+func SanitizeFileName(name string, contentType string) string {
+	// Only the last element counts, for both separators
+	name = path.Base(strings.ReplaceAll(toUTF8(name), `\`, "/"))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r):
+			continue
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	name = strings.TrimLeft(b.String(), ". ")
+	name = strings.TrimRight(name, ". ")
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		base = "datei"
+	}
+	if ext == "" {
+		ext = extensionOf(contentType)
+	}
+	if reservedNames[strings.ToUpper(strings.TrimRight(strings.SplitN(base, ".", 2)[0], " "))] {
+		base = "_" + base
+	}
+
+	// Shorten the base on a rune boundary and keep the extension
+	for len(base)+len(ext) > maxFileNameLength && base != "" {
+		_, size := utf8.DecodeLastRuneInString(base)
+		base = base[:len(base)-size]
+	}
+	if len(ext) > maxFileNameLength {
+		ext = ""
+	}
+	return strings.TrimRight(base, ". ") + ext
+}
+
+// extensionOf returns the file extension of a content type or "" if it is unknown
+func extensionOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if ext, ok := extensions[mediaType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// SaveFileToDir downloads a file of PRO/Datei into dir and returns the result with the final path.
+// The name sent by PROFFIX is sanitized with SanitizeFileName; if a file with this name exists,
+// " (1)", " (2)", ... is appended, so existing files are never overwritten.
+func (c *Client) SaveFileToDir(ctx context.Context, dir string, dateinr string) (*DownloadResult, error) {
+	dir = filepath.Clean(dir)
+	tmp, err := os.CreateTemp(dir, ".download-*.part")
+	if err != nil {
+		return nil, err
+	}
+	tmpName := tmp.Name()
+	_ = tmp.Close()
+	defer func() { _ = os.Remove(tmpName) }()
+
+	endpoint := "PRO/Datei/" + dateinr
+	res, err := c.download(ctx, endpoint, tmpName, DownloadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	name := SanitizeFileName(res.FileName, res.ContentType)
+	target, err := reserveFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmpName, 0o644); err != nil { // #nosec G302 -- same permissions as WriteFile
+		_ = os.Remove(target)
+		return nil, err
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		_ = os.Remove(target)
+		return nil, err
+	}
+	res.Path = target
+
+	logDebug(ctx, c, fmt.Sprintf("Saved %s as %s (%d bytes)", endpoint, target, res.Size))
+	return res, nil
+}
+
+// reserveFile creates an empty file for name in dir, adding a counter if the name is taken
+func reserveFile(dir string, name string) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 0; i < 10000; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		}
+		target := filepath.Join(dir, candidate)
+
+		// Defense in depth: the sanitized name must stay inside dir
+		if filepath.Dir(target) != dir || filepath.Base(target) != candidate {
+			return "", &PxError{Type: "INVALID_FILENAME", Message: fmt.Sprintf("File name %q leaves the directory", name)}
+		}
+
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) // #nosec G304 -- sanitized name inside dir
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_ = file.Close()
+		return target, nil
+	}
+	return "", &PxError{Type: "INVALID_FILENAME", Message: fmt.Sprintf("No free file name for %q", name)}
+}
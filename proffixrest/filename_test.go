@@ -0,0 +1,137 @@
+package proffixrest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		expected    string
+	}{
+		{"Rechnung.pdf", "application/pdf", "Rechnung.pdf"},
+		{"../../etc/passwd", "", "passwd"},
+		{`..\..\Windows\win.ini`, "", "win.ini"},
+		{"/tmp/x.pdf", "", "x.pdf"},
+		{"..", "application/pdf", "datei.pdf"},
+		{"", "application/pdf", "datei.pdf"},
+		{"", "", "datei"},
+		{"Offerte", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "Offerte.docx"},
+		{"CON.txt", "", "_CON.txt"},
+		{"lpt1", "", "_lpt1"},
+		{"a<b>c:d|e?f*g\".pdf", "", "a_b_c_d_e_f_g_.pdf"},
+		{"zeile\r\nzwei\x00.pdf", "", "zeilezwei.pdf"},
+		{".versteckt", "", "versteckt"},
+		{"Notiz. . ", "text/plain", "Notiz.txt"},
+		{"Grüsse.pdf", "", "Grüsse.pdf"},
+		{"Gr\xfcsse.pdf", "", "Grüsse.pdf"},
+	}
+	for _, test := range tests {
+		if got := SanitizeFileName(test.name, test.contentType); got != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.name, got)
+		}
+	}
+
+	long := SanitizeFileName(strings.Repeat("ä", 150)+".pdf", "")
+	if len(long) > maxFileNameLength || !utf8.ValidString(long) || !strings.HasSuffix(long, ".pdf") {
+		t.Errorf("Expected valid name of at most %d bytes with extension, got %d bytes: %q", maxFileNameLength, len(long), long)
+	}
+}
+
+func TestFileNameFromHeader(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{`attachment; filename="Liste.pdf"`, "Liste.pdf"},
+		{`attachment; filename="Gruesse.pdf"; filename*=UTF-8''Gr%C3%BCsse.pdf`, "Grüsse.pdf"},
+		{`attachment; filename*=iso-8859-1'de'Gr%FCsse.pdf`, "Grüsse.pdf"},
+		{"attachment; filename=\"Gr\xfcsse.pdf\"", "Grüsse.pdf"},
+		{`attachment; filename=Liste Mai.pdf`, "Liste Mai.pdf"},
+		{`attachment`, ""},
+		{``, ""},
+	}
+	for _, test := range tests {
+		if got := fileNameFromHeader(http.Header{"Content-Disposition": {test.header}}); got != test.expected {
+			t.Errorf("Expected %q for %q, got %q", test.expected, test.header, got)
+		}
+	}
+}
+
+func TestClient_SaveFileToDir(t *testing.T) {
+	data := []byte("PDF-Inhalt")
+	px := newFakePX(t, nil)
+	var disposition atomic.Value
+	disposition.Store(`attachment; filename="../../Liste.pdf"`)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint != "PRO/Datei/1" {
+			return false
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", disposition.Load().(string))
+		_, _ = w.Write(data)
+		return true
+	}
+	c := px.client(nil)
+
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "ablage")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	first, err := c.SaveFileToDir(context.Background(), dir, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Path != filepath.Join(dir, "Liste.pdf") || first.FileName != "../../Liste.pdf" {
+		t.Errorf("Expected file inside dir, got %s (sent %s)", first.Path, first.FileName)
+	}
+
+	// Existing files are never overwritten
+	second, err := c.SaveFileToDir(context.Background(), dir, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if second.Path != filepath.Join(dir, "Liste (1).pdf") {
+		t.Errorf("Expected de-duplicated name, got %s", second.Path)
+	}
+	if content, _ := os.ReadFile(second.Path); string(content) != string(data) {
+		t.Errorf("Expected file content %q, got %q", data, content)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 files and no temporary files, got %d entries", len(entries))
+	}
+	if outside, _ := os.ReadDir(parent); len(outside) != 1 {
+		t.Errorf("Expected nothing to be written outside dir, got %d entries", len(outside))
+	}
+
+	// Without a name the extension comes from the content type
+	disposition.Store("attachment")
+	third, err := c.SaveFileToDir(context.Background(), dir, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if filepath.Base(third.Path) != "datei.pdf" {
+		t.Errorf("Expected datei.pdf, got %s", third.Path)
+	}
+}
+
+func TestReserveFile_Traversal(t *testing.T) {
+	_, err := reserveFile(t.TempDir(), "../x.pdf")
+	var pxErr *PxError
+	if !errors.As(err, &pxErr) || pxErr.Type != "INVALID_FILENAME" {
+		t.Errorf("Expected INVALID_FILENAME error, got %v", err)
+	}
+}
@@ -85,24 +85,59 @@ func GetMap(rc io.Reader) (items map[string]interface{}, err error) {
 	return it, nil
 }
 
+// GetMapsUseNumber returns []map[string]interface{} from io.Reader with numbers as json.Number.
+// Use it for amounts, which can then be converted exactly with decimal.NewFromJSONNumber.
+func GetMapsUseNumber(rc io.Reader) (items []map[string]interface{}, err error) {
+	resp, err := ReaderToByte(rc)
+	if err != nil {
+		return nil, err
+	}
+	if err = unmarshalUseNumber(resp, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetMapUseNumber returns map[string]interface{} from io.Reader with numbers as json.Number.
+func GetMapUseNumber(rc io.Reader) (items map[string]interface{}, err error) {
+	var it = map[string]interface{}{}
+	res, err := ReaderToByte(rc)
+	if err != nil {
+		return nil, err
+	}
+	if err = unmarshalUseNumber(res, &it); err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// unmarshalUseNumber decodes JSON like json.Unmarshal but keeps numbers as json.Number
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
 // GetFileTokens returns map[string]string{} from io.Reader.
+// Numeric keys and file numbers are converted to strings; entries without key and empty file fields are skipped.
 func GetFileTokens(rc io.Reader, keyField string, fileField string) (files []map[string][]string, err error) {
 	if fileField == "" {
 		fileField = "DateiNr"
 	}
 
-	items, err := GetMaps(rc)
+	items, err := GetMapsUseNumber(rc)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, mp := range items {
-		key := mp[keyField].(string)
+		key := itemKey(mp, keyField)
+		if key == "" {
+			continue
+		}
 		var tmpArr []string
-		for k, v := range mp {
-			if k == fileField {
-				tmpArr = append(tmpArr, v.(string))
-			}
+		if dateiNr := fileRef(mp[fileField]); dateiNr != "" {
+			tmpArr = append(tmpArr, dateiNr)
 		}
 
 		files = append(files, map[string][]string{key: tmpArr})
@@ -110,6 +145,19 @@ func GetFileTokens(rc io.Reader, keyField string, fileField string) (files []map
 	return files, nil
 }
 
+// fileRef returns the DateiNr of a file field, which is either the DateiNr itself or an object containing it
+func fileRef(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		return fileRef(v["DateiNr"])
+	}
+	return ""
+}
+
 // GetUsedLicences returns total amount of used licences.
 func GetUsedLicences(res io.Reader) (total float64, err error) {
 	mp, err := GetMap(res)
@@ -124,3 +172,30 @@ func GetUsedLicences(res io.Reader) (total float64, err error) {
 	}
 	return total, nil
 }
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it to path,
+// so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
@@ -3,9 +3,11 @@ package proffixrest
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -132,6 +134,38 @@ func TestGetMap(t *testing.T) {
 	}
 }
 
+func TestGetMapsUseNumber(t *testing.T) {
+	testJSON := `[{"Betrag":0.1},{"Betrag":1234567890123456789.12}]`
+
+	result, err := GetMapsUseNumber(strings.NewReader(testJSON))
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 items, got %v", len(result))
+	}
+
+	if result[1]["Betrag"] != json.Number("1234567890123456789.12") {
+		t.Errorf("Expected exact json.Number, got %v", result[1]["Betrag"])
+	}
+}
+
+func TestGetMapUseNumber(t *testing.T) {
+	result, err := GetMapUseNumber(strings.NewReader(`{"Menge":2.50}`))
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if result["Menge"] != json.Number("2.50") {
+		t.Errorf("Expected json.Number 2.50, got %v", result["Menge"])
+	}
+
+	if _, err := GetMapUseNumber(strings.NewReader(`[1]`)); err == nil {
+		t.Errorf("Expected error for JSON array")
+	}
+}
+
 func TestWriteFile(t *testing.T) {
 	// Create temp file path
 	tempFile := "test_write_file.txt"
@@ -203,3 +237,56 @@ func TestGetFileTokens(t *testing.T) {
 		t.Errorf("Expected key '123' in first item")
 	}
 }
+
+func TestGetFileTokens_NumbersAndNull(t *testing.T) {
+	testJSON := `[
+		{"AdressNr":1,"DateiNr":"456"},
+		{"AdressNr":2,"DateiNr":null},
+		{"AdressNr":3,"DateiNr":{"DateiNr":"789"}},
+		{"DateiNr":"000"}
+	]`
+
+	files, err := GetFileTokens(strings.NewReader(testJSON), "AdressNr", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 entries with key, got %v", len(files))
+	}
+	if len(files[0]["1"]) != 1 || files[0]["1"][0] != "456" {
+		t.Errorf("Expected numeric key 1 with file 456, got %v", files[0])
+	}
+	if len(files[1]["2"]) != 0 {
+		t.Errorf("Expected no file for null field, got %v", files[1])
+	}
+	if len(files[2]["3"]) != 1 || files[2]["3"][0] != "789" {
+		t.Errorf("Expected file 789 of nested object, got %v", files[2])
+	}
+}
+
+func Test_writeFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := writeFileAtomic(path, []byte(`{"a":1}`), 0o600); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := writeFileAtomic(path, []byte(`{"a":2}`), 0o600); err != nil {
+		t.Fatalf("Expected no error on overwrite, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != `{"a":2}` {
+		t.Errorf("Expected new content, got %s (%v)", data, err)
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the target file, got %v entries", len(entries))
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "state.json"), []byte("x"), 0o600); err == nil {
+		t.Errorf("Expected error for a missing directory")
+	}
+}
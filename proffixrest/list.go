@@ -2,26 +2,35 @@ package proffixrest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/models"
 )
 
+// listCatalog caches the entries of PRO/Liste of a database
+type listCatalog struct {
+	mu       sync.Mutex
+	database string
+	lists    []models.Liste
+	expires  time.Time
+}
+
 // GetList generates a list on the PROFFIX REST-API and downloads its file representation.
 func (c *Client) GetList(ctx context.Context, listenr int, body interface{}) (io.ReadCloser, http.Header, int, error) {
 
-	// Build query for getting download URL of List
-	resp, headers, status, err := c.Post(ctx, "PRO/Liste/"+strconv.Itoa(listenr)+"/generieren", body)
-
+	dateiNr, resp, headers, status, err := c.generateList(ctx, listenr, body)
 	// If err not nil or status not 201
 	if err != nil || status != 201 {
-		logDebug(ctx, c, fmt.Sprintf("Error on create list: %v, PxSession-ID: %v", err, c.GetPxSessionID()))
 		return resp, headers, status, err
 	}
-
-	// Build Download Uri - was change in Px Rest-API 4.43 / 4.42. This fix works for all...
-	dateiNr := ConvertLocationToID(headers)
 	downloadURI := "PRO/Datei/" + dateiNr
 
 	// If Log enabled log URL
@@ -39,3 +48,129 @@ func (c *Client) GetList(ctx context.Context, listenr int, body interface{}) (io
 	return downloadFile, headersDownload, statusDownload, err
 
 }
+
+// generateList generates a list and returns the DateiNr of its file
+func (c *Client) generateList(ctx context.Context, listenr int, body interface{}) (string, io.ReadCloser, http.Header, int, error) {
+	// Build query for getting download URL of List
+	resp, headers, status, err := c.Post(ctx, "PRO/Liste/"+strconv.Itoa(listenr)+"/generieren", body)
+
+	// If err not nil or status not 201
+	if err != nil || status != 201 {
+		logDebug(ctx, c, fmt.Sprintf("Error on create list: %v, PxSession-ID: %v", err, c.GetPxSessionID()))
+		return "", resp, headers, status, err
+	}
+
+	// Only the Location header is needed
+	closeBody(resp)
+
+	// Build Download Uri - was change in Px Rest-API 4.43 / 4.42. This fix works for all...
+	return ConvertLocationToID(headers), nil, headers, status, nil
+}
+
+// GetListByName generates the list with the given name like GetList.
+// The name is resolved with FindListByName, so configurations do not depend on the ListeNr of a database.
+func (c *Client) GetListByName(ctx context.Context, name string, body interface{}) (io.ReadCloser, http.Header, int, error) {
+	liste, err := c.FindListByName(ctx, name)
+	if err != nil {
+		var status int
+		var pxErr *PxError
+		if errors.As(err, &pxErr) {
+			status = pxErr.Status
+		}
+		return nil, nil, status, err
+	}
+	return c.GetList(ctx, liste.ListeNr, body)
+}
+
+// Lists returns the catalogue of PRO/Liste. It is cached per database for Options.ListCacheTTL.
+// If PROFFIX sends no Modul, it is taken from the prefix of the name (e.g. ADR of ADR_Adressliste.repx).
+func (c *Client) Lists(ctx context.Context) ([]models.Liste, error) {
+	c.lists.mu.Lock()
+	defer c.lists.mu.Unlock()
+
+	if c.lists.lists != nil && c.lists.database == c.Datenbank && time.Now().Before(c.lists.expires) {
+		return append([]models.Liste(nil), c.lists.lists...), nil
+	}
+
+	rc, _, _, err := c.Get(WithoutCache(ctx), "PRO/Liste", nil)
+	if err != nil {
+		return nil, err
+	}
+	lists := []models.Liste{}
+	if err := decodeBody(rc, &lists); err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		if lists[i].Modul == "" {
+			if prefix, _, ok := strings.Cut(lists[i].Name, "_"); ok && len(prefix) == 3 {
+				lists[i].Modul = strings.ToUpper(prefix)
+			}
+		}
+	}
+
+	c.lists.database = c.Datenbank
+	c.lists.lists = lists
+	c.lists.expires = time.Now().Add(c.option.ListCacheTTL)
+	logDebug(ctx, c, fmt.Sprintf("Loaded %d lists of PRO/Liste", len(lists)))
+	return append([]models.Liste(nil), lists...), nil
+}
+
+// InvalidateLists removes the cached catalogue, so the next call of Lists loads it again.
+func (c *Client) InvalidateLists() {
+	c.lists.mu.Lock()
+	defer c.lists.mu.Unlock()
+	c.lists.lists = nil
+}
+
+// FindLists returns the lists whose Name or Bezeichnung matches pattern, ignoring case.
+// pattern uses the syntax of path.Match, e.g. "ADR_*" or "*etiketten*".
+func (c *Client) FindLists(ctx context.Context, pattern string) ([]models.Liste, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, &PxError{Endpoint: "PRO/Liste", Type: "INVALID_INPUT", Message: fmt.Sprintf("Invalid pattern %q: %s", pattern, err)}
+	}
+
+	lists, err := c.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []models.Liste
+	for _, liste := range lists {
+		nameMatch, _ := path.Match(pattern, strings.ToLower(liste.Name))
+		descMatch, _ := path.Match(pattern, strings.ToLower(liste.Bezeichnung))
+		if nameMatch || descMatch {
+			found = append(found, liste)
+		}
+	}
+	return found, nil
+}
+
+// FindListByName returns the list with the given name, ignoring case. The extension may be omitted.
+// Returns an error of Type "NOT_FOUND" if there is no such list and "MULTIPLE_MATCHES" if the name is ambiguous.
+func (c *Client) FindListByName(ctx context.Context, name string) (*models.Liste, error) {
+	lists, err := c.Lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []models.Liste
+	for _, liste := range lists {
+		if strings.EqualFold(liste.Name, name) {
+			// An exact match wins over names without extension
+			return &liste, nil
+		}
+		if strings.EqualFold(strings.TrimSuffix(liste.Name, path.Ext(liste.Name)), name) {
+			found = append(found, liste)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, &PxError{Endpoint: "PRO/Liste", Status: http.StatusNotFound, Type: "NOT_FOUND", Message: fmt.Sprintf("List %q not found", name)}
+	case 1:
+		return &found[0], nil
+	default:
+		return nil, &PxError{Endpoint: "PRO/Liste", Type: "MULTIPLE_MATCHES", Message: fmt.Sprintf("%d lists match %q", len(found), name)}
+	}
+}
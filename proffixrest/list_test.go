@@ -2,7 +2,9 @@ package proffixrest
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 )
 
 // TestClient_GetList
@@ -131,3 +133,102 @@ func TestClient_GetList_NilBody(t *testing.T) {
 		t.Errorf("Server error for GetList with nil body: %v", err)
 	}
 }
+
+// newListFake serves a catalogue of PRO/Liste and generates list 12
+func newListFake(t *testing.T) *fakePX {
+	t.Helper()
+	px := newFakePX(t, nil)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		switch {
+		case r.Method == http.MethodGet && endpoint == "PRO/Liste":
+			writeJSON(w, []map[string]interface{}{
+				{"ListeNr": 12, "Name": "ADR_Adress-Etiketten Kontakte.labx", "Bezeichnung": "Etiketten Kontakte"},
+				{"ListeNr": 15, "Name": "ADR_Adressliste.repx", "Bezeichnung": "Adressliste", "Modul": "ADR", "Typ": "Liste"},
+				{"ListeNr": 16, "Name": "ADR_Adressliste.labx", "Bezeichnung": "Adressliste als Etikette"},
+				{"ListeNr": 30, "Name": "AUF_Rechnung.repx", "Bezeichnung": "Rechnung"},
+			})
+		case r.Method == http.MethodPost && endpoint == "PRO/Liste/12/generieren":
+			w.Header().Set("Location", px.server.URL+"/pxapi/v4/PRO/Datei/L12")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && endpoint == "PRO/Datei/L12":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF"))
+		default:
+			return false
+		}
+		return true
+	}
+	return px
+}
+
+func TestClient_Lists_Catalogue(t *testing.T) {
+	px := newListFake(t)
+	c := px.client(&Options{ListCacheTTL: time.Hour})
+	ctx := context.Background()
+
+	lists, err := c.Lists(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(lists) != 4 || lists[0].ListeNr != 12 || lists[0].Modul != "ADR" || lists[3].Modul != "AUF" {
+		t.Errorf("Expected 4 lists with module, got %+v", lists)
+	}
+
+	found, err := c.FindLists(ctx, "*etikette*")
+	if err != nil || len(found) != 2 {
+		t.Errorf("Expected 2 lists matching Name or Bezeichnung, got %+v (%v)", found, err)
+	}
+	if found, _ := c.FindLists(ctx, "auf_*"); len(found) != 1 || found[0].ListeNr != 30 {
+		t.Errorf("Expected list 30 for auf_*, got %+v", found)
+	}
+	if _, err := c.FindLists(ctx, "[adr"); err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	}
+
+	// The catalogue is loaded only once
+	if n := px.callCount("GET", "PRO/Liste"); n != 1 {
+		t.Errorf("Expected 1 request of the catalogue, got %d", n)
+	}
+	c.InvalidateLists()
+	if _, err := c.Lists(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n := px.callCount("GET", "PRO/Liste"); n != 2 {
+		t.Errorf("Expected catalogue to be loaded again after InvalidateLists, got %d requests", n)
+	}
+}
+
+func TestClient_FindListByName(t *testing.T) {
+	px := newListFake(t)
+	c := px.client(nil)
+	ctx := context.Background()
+
+	liste, err := c.FindListByName(ctx, "adr_adress-etiketten kontakte")
+	if err != nil || liste.ListeNr != 12 {
+		t.Errorf("Expected list 12 without extension, got %+v (%v)", liste, err)
+	}
+	if liste, err := c.FindListByName(ctx, "ADR_Adressliste.labx"); err != nil || liste.ListeNr != 16 {
+		t.Errorf("Expected list 16 for the exact name, got %+v (%v)", liste, err)
+	}
+
+	_, err = c.FindListByName(ctx, "ADR_Adressliste")
+	if pxErr, ok := err.(*PxError); !ok || pxErr.Type != "MULTIPLE_MATCHES" {
+		t.Errorf("Expected MULTIPLE_MATCHES for an ambiguous name, got %v", err)
+	}
+	_, err = c.FindListByName(ctx, "Unbekannt.repx")
+	if pxErr, ok := err.(*PxError); !ok || !pxErr.isNotFound() {
+		t.Errorf("Expected NOT_FOUND, got %v", err)
+	}
+
+	rc, headers, status, err := c.GetListByName(ctx, "ADR_Adress-Etiketten Kontakte.labx", nil)
+	if err != nil || status != http.StatusOK || headers.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("Expected generated PDF, got status %d (%v)", status, err)
+	}
+	if data, _ := ReaderToByte(rc); string(data) != "%PDF" {
+		t.Errorf("Expected file content, got %q", data)
+	}
+
+	if _, _, status, err := c.GetListByName(ctx, "Unbekannt", nil); err == nil || status != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown list, got %d (%v)", status, err)
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import "github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+
+// Adresse is an address of endpoint ADR/Adresse.
+type Adresse struct {
+	AdressNr     int              `json:"AdressNr,omitempty"`
+	Suchname     string           `json:"Suchname,omitempty"`
+	Name         string           `json:"Name,omitempty"`
+	Vorname      *string          `json:"Vorname,omitempty"`
+	Zusatz       *string          `json:"Zusatz,omitempty"`
+	Strasse      *string          `json:"Strasse,omitempty"`
+	Postfach     *string          `json:"Postfach,omitempty"`
+	PLZ          string           `json:"PLZ,omitempty"`
+	Ort          string           `json:"Ort,omitempty"`
+	Kanton       *string          `json:"Kanton,omitempty"`
+	Land         *LandRef         `json:"Land,omitempty"`
+	Telefon1     *string          `json:"Telefon1,omitempty"`
+	Telefon2     *string          `json:"Telefon2,omitempty"`
+	Natel        *string          `json:"Natel,omitempty"`
+	Fax          *string          `json:"Fax,omitempty"`
+	EMail        *string          `json:"EMail,omitempty"`
+	URL          *string          `json:"Url,omitempty"`
+	UIDNr        *string          `json:"UIDNr,omitempty"`
+	Geburtsdatum *pxtime.Date     `json:"Geburtsdatum,omitempty"`
+	Bemerkungen  *string          `json:"Bemerkungen,omitempty"`
+	Geloescht    *bool            `json:"Geloescht,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
+
+// Kontakt is a contact person of endpoint ADR/Kontakt.
+type Kontakt struct {
+	KontaktNr    int              `json:"KontaktNr,omitempty"`
+	Adresse      *AdresseRef      `json:"Adresse,omitempty"`
+	Anrede       *string          `json:"Anrede,omitempty"`
+	Titel        *string          `json:"Titel,omitempty"`
+	Name         string           `json:"Name,omitempty"`
+	Vorname      *string          `json:"Vorname,omitempty"`
+	Funktion     *string          `json:"Funktion,omitempty"`
+	Abteilung    *string          `json:"Abteilung,omitempty"`
+	Telefon      *string          `json:"Telefon,omitempty"`
+	Natel        *string          `json:"Natel,omitempty"`
+	EMail        *string          `json:"EMail,omitempty"`
+	Geburtsdatum *pxtime.Date     `json:"Geburtsdatum,omitempty"`
+	Bemerkungen  *string          `json:"Bemerkungen,omitempty"`
+	Geloescht    *bool            `json:"Geloescht,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
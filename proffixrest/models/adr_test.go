@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+func TestAdresse_JSON(t *testing.T) {
+	var a Adresse
+	err := json.Unmarshal([]byte(`{"AdressNr":276,"Vorname":null,"Geburtsdatum":"1980-05-17 00:00:00","ErstelltAm":"2024-03-01 08:15:30","GeaendertAm":""}`), &a)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if a.AdressNr != 276 {
+		t.Errorf("Expected AdressNr 276, got %v", a.AdressNr)
+	}
+	if a.Vorname != nil {
+		t.Errorf("Expected Vorname nil, got %v", *a.Vorname)
+	}
+	if a.Geburtsdatum == nil || a.Geburtsdatum.String() != "1980-05-17" {
+		t.Errorf("Expected Geburtsdatum 1980-05-17, got %v", a.Geburtsdatum)
+	}
+	if a.ErstelltAm == nil || !a.ErstelltAm.Equal(time.Date(2024, 3, 1, 8, 15, 30, 0, pxtime.Location())) {
+		t.Errorf("Expected ErstelltAm 2024-03-01 08:15:30, got %v", a.ErstelltAm)
+	}
+	if a.GeaendertAm == nil || !a.GeaendertAm.IsZero() {
+		t.Errorf("Expected zero GeaendertAm for empty string, got %v", a.GeaendertAm)
+	}
+
+	geburtsdatum := pxtime.NewDate(time.Date(1980, 5, 17, 0, 0, 0, 0, pxtime.Location()))
+	out, err := json.Marshal(Adresse{AdressNr: 1, Geburtsdatum: &geburtsdatum})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(out) != `{"AdressNr":1,"Geburtsdatum":"1980-05-17"}` {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+}
+
+func TestAdresse_InvalidDate(t *testing.T) {
+	var a Adresse
+	if err := json.Unmarshal([]byte(`{"ErstelltAm":"17.05.1980"}`), &a); err == nil {
+		t.Errorf("Expected error for invalid timestamp")
+	}
+}
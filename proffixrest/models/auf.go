@@ -0,0 +1,38 @@
+package models
+
+import (
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+// Dokument is an order document (offer, order, invoice...) of endpoint AUF/Dokument.
+type Dokument struct {
+	DokumentNr   int                `json:"DokumentNr,omitempty"`
+	Dokumenttyp  *DokumenttypRef    `json:"Dokumenttyp,omitempty"`
+	Adresse      *AdresseRef        `json:"Adresse,omitempty"`
+	Datum        *pxtime.Date       `json:"Datum,omitempty"`
+	Waehrung     *WaehrungRef       `json:"Waehrung,omitempty"`
+	Referenz     *string            `json:"Referenz,omitempty"`
+	Bemerkungen  *string            `json:"Bemerkungen,omitempty"`
+	TotalNetto   *decimal.Decimal   `json:"TotalNetto,omitempty"`
+	TotalBrutto  *decimal.Decimal   `json:"TotalBrutto,omitempty"`
+	Positionen   []Dokumentposition `json:"Positionen,omitempty"`
+	ErstelltAm   *pxtime.DateTime   `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string             `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime   `json:"GeaendertAm,omitempty"`
+	GeaendertVon string             `json:"GeaendertVon,omitempty"`
+}
+
+// Dokumentposition is a position of an AUF document (endpoint AUF/Dokument/{DokumentNr}/Position).
+type Dokumentposition struct {
+	PositionNr   int              `json:"PositionNr,omitempty"`
+	Artikel      *ArtikelRef      `json:"Artikel,omitempty"`
+	Bezeichnung1 *string          `json:"Bezeichnung1,omitempty"`
+	Bezeichnung2 *string          `json:"Bezeichnung2,omitempty"`
+	Menge        *decimal.Decimal `json:"Menge,omitempty"`
+	Einheit      *EinheitRef      `json:"Einheit,omitempty"`
+	Preis        *decimal.Decimal `json:"Preis,omitempty"`
+	Rabatt       *decimal.Decimal `json:"Rabatt,omitempty"`
+	Total        *decimal.Decimal `json:"Total,omitempty"`
+	Lager        *LagerRef        `json:"Lager,omitempty"`
+}
@@ -0,0 +1,8 @@
+// Package models contains Go structs for the most common resources of the PROFFIX REST-API.
+//
+// The structs carry the JSON field names used by PROFFIX. Fields which PROFFIX may return as null
+// are pointers, references to other resources are pointer structs holding only their key.
+// Timestamps and dates use the types of package pxtime, amounts and quantities the exact type of package decimal.
+// Customer specific fields (Z_ fields) are not part of these models; embed the struct and add them
+// in your own type if needed.
+package models
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+// Buchung is a ledger booking of endpoint FIB/Buchung.
+type Buchung struct {
+	BuchungNr    int              `json:"BuchungNr,omitempty"`
+	Datum        *pxtime.Date     `json:"Datum,omitempty"`
+	Beleg        *string          `json:"Beleg,omitempty"`
+	SollKonto    *KontoRef        `json:"SollKonto,omitempty"`
+	HabenKonto   *KontoRef        `json:"HabenKonto,omitempty"`
+	Betrag       *decimal.Decimal `json:"Betrag,omitempty"`
+	Waehrung     *WaehrungRef     `json:"Waehrung,omitempty"`
+	Kurs         *decimal.Decimal `json:"Kurs,omitempty"`
+	Text         string           `json:"Text,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
+
+// Konto is a ledger account of endpoint FIB/Konto.
+type Konto struct {
+	KontoNr      int              `json:"KontoNr,omitempty"`
+	Bezeichnung  string           `json:"Bezeichnung,omitempty"`
+	Kontotyp     *string          `json:"Kontotyp,omitempty"`
+	Waehrung     *WaehrungRef     `json:"Waehrung,omitempty"`
+	Gesperrt     *bool            `json:"Gesperrt,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+)
+
+func TestBuchung_ExactAmounts(t *testing.T) {
+	var buchungen []Buchung
+	err := json.Unmarshal([]byte(`[{"BuchungNr":1,"Betrag":0.1},{"BuchungNr":2,"Betrag":0.2},{"BuchungNr":3,"Betrag":null}]`), &buchungen)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	total := decimal.Zero
+	for _, b := range buchungen {
+		if b.Betrag != nil {
+			total = total.Add(*b.Betrag)
+		}
+	}
+	if total.String() != "0.3" {
+		t.Errorf("Expected total 0.3, got %s", total)
+	}
+	if buchungen[2].Betrag != nil {
+		t.Errorf("Expected Betrag nil, got %v", buchungen[2].Betrag)
+	}
+
+	out, _ := json.Marshal(buchungen[0])
+	if string(out) != `{"BuchungNr":1,"Betrag":0.1}` {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+// Artikel is an article of endpoint LAG/Artikel.
+type Artikel struct {
+	ArtikelNr         string           `json:"ArtikelNr,omitempty"`
+	Bezeichnung1      string           `json:"Bezeichnung1,omitempty"`
+	Bezeichnung2      *string          `json:"Bezeichnung2,omitempty"`
+	Bezeichnung3      *string          `json:"Bezeichnung3,omitempty"`
+	Bezeichnung4      *string          `json:"Bezeichnung4,omitempty"`
+	Bezeichnung5      *string          `json:"Bezeichnung5,omitempty"`
+	Verkaufspreis1    *decimal.Decimal `json:"Verkaufspreis1,omitempty"`
+	Verkaufspreis2    *decimal.Decimal `json:"Verkaufspreis2,omitempty"`
+	Einkaufspreis     *decimal.Decimal `json:"Einkaufspreis,omitempty"`
+	Gewicht           *decimal.Decimal `json:"Gewicht,omitempty"`
+	EinheitLager      *EinheitRef      `json:"EinheitLager,omitempty"`
+	EinheitRechnung   *EinheitRef      `json:"EinheitRechnung,omitempty"`
+	Waehrung          *WaehrungRef     `json:"Waehrung,omitempty"`
+	Steuercode        *SteuercodeRef   `json:"Steuercode,omitempty"`
+	SteuercodeEinkauf *SteuercodeRef   `json:"SteuercodeEinkauf,omitempty"`
+	Ertragskonto      *KontoRef        `json:"Ertragskonto,omitempty"`
+	KeinBestand       *bool            `json:"KeinBestand,omitempty"`
+	Gesperrt          *bool            `json:"Gesperrt,omitempty"`
+	Bemerkungen       *string          `json:"Bemerkungen,omitempty"`
+	Geloescht         *bool            `json:"Geloescht,omitempty"`
+	ErstelltAm        *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon       string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm       *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon      string           `json:"GeaendertVon,omitempty"`
+}
+
+// Lager is a warehouse of endpoint LAG/Lager.
+type Lager struct {
+	LagerNr      string           `json:"LagerNr,omitempty"`
+	Bezeichnung  string           `json:"Bezeichnung,omitempty"`
+	Standort     *string          `json:"Standort,omitempty"`
+	Inaktiv      *bool            `json:"Inaktiv,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+
+// Info is the response of endpoint PRO/Info.
+type Info struct {
+	Version        string          `json:"Version"`
+	ServerZeit     pxtime.DateTime `json:"ServerZeit"`
+	NeuesteVersion string          `json:"NeuesteVersion"`
+	Instanz        Instanz         `json:"Instanz"`
+}
+
+// Instanz describes the PROFFIX instance and its licences.
+type Instanz struct {
+	InstanzNr string   `json:"InstanzNr"`
+	Name      string   `json:"Name"`
+	Lizenzen  []Lizenz `json:"Lizenzen"`
+}
+
+// Lizenz is a licence entry of a PROFFIX instance.
+type Lizenz struct {
+	Name               string       `json:"Name"`
+	Bezeichnung        string       `json:"Bezeichnung"`
+	Anzahl             int          `json:"Anzahl"`
+	AnzahlInVerwendung int          `json:"AnzahlInVerwendung"`
+	Demo               bool         `json:"Demo"`
+	Ablaufdatum        *pxtime.Date `json:"Ablaufdatum"`
+}
+
+// Datenbank is an entry of endpoint PRO/Datenbank.
+type Datenbank struct {
+	Name        string `json:"Name"`
+	Bezeichnung string `json:"Bezeichnung,omitempty"`
+}
+
+// Anhang is a document linked to an entry, e.g. of endpoint ADR/Adresse/{AdressNr}/Dokument.
+type Anhang struct {
+	DokumentNr   int              `json:"DokumentNr,omitempty"`
+	DateiNr      string           `json:"DateiNr,omitempty"`
+	Bezeichnung  string           `json:"Bezeichnung,omitempty"`
+	Dateiname    *string          `json:"Dateiname,omitempty"`
+	Bemerkungen  *string          `json:"Bemerkungen,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
+
+// Liste is a list (report) of endpoint PRO/Liste, generated with PRO/Liste/{ListeNr}/generieren.
+type Liste struct {
+	ListeNr     int    `json:"ListeNr"`
+	Name        string `json:"Name"` // File name, e.g. ADR_Adress-Etiketten Kontakte.labx
+	Bezeichnung string `json:"Bezeichnung,omitempty"`
+	Modul       string `json:"Modul,omitempty"`
+	Typ         string `json:"Typ,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+// LandRef references a country by its LandNr (e.g. CH).
+type LandRef struct {
+	LandNr string `json:"LandNr,omitempty"`
+}
+
+// AdresseRef references an address by its AdressNr.
+type AdresseRef struct {
+	AdressNr int `json:"AdressNr,omitempty"`
+}
+
+// ArtikelRef references an article by its ArtikelNr.
+type ArtikelRef struct {
+	ArtikelNr string `json:"ArtikelNr,omitempty"`
+}
+
+// EinheitRef references a unit by its EinheitNr (e.g. STK).
+type EinheitRef struct {
+	EinheitNr string `json:"EinheitNr,omitempty"`
+}
+
+// WaehrungRef references a currency by its WaehrungNr (e.g. CHF).
+type WaehrungRef struct {
+	WaehrungNr string `json:"WaehrungNr,omitempty"`
+}
+
+// SteuercodeRef references a tax code by its SteuercodeNr.
+type SteuercodeRef struct {
+	SteuercodeNr int `json:"SteuercodeNr,omitempty"`
+}
+
+// KontoRef references a ledger account by its KontoNr.
+type KontoRef struct {
+	KontoNr int `json:"KontoNr,omitempty"`
+}
+
+// LagerRef references a warehouse by its LagerNr.
+type LagerRef struct {
+	LagerNr string `json:"LagerNr,omitempty"`
+}
+
+// DokumenttypRef references an AUF document type by its DokumenttypNr (e.g. RE).
+type DokumenttypRef struct {
+	DokumenttypNr string `json:"DokumenttypNr,omitempty"`
+}
+
+// MitarbeiterRef references an employee by its MitarbeiterNr.
+type MitarbeiterRef struct {
+	MitarbeiterNr string `json:"MitarbeiterNr,omitempty"`
+}
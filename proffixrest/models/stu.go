@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
+)
+
+// Rapport is a time report of endpoint STU/Rapporte.
+type Rapport struct {
+	RapportNr    int              `json:"RapportNr,omitempty"`
+	Datum        *pxtime.Date     `json:"Datum,omitempty"`
+	Adresse      *AdresseRef      `json:"Adresse,omitempty"`
+	Mitarbeiter  *MitarbeiterRef  `json:"Mitarbeiter,omitempty"`
+	Artikel      *ArtikelRef      `json:"Artikel,omitempty"`
+	Zeit         *decimal.Decimal `json:"Zeit,omitempty"`
+	Text         *string          `json:"Text,omitempty"`
+	Verrechenbar *bool            `json:"Verrechenbar,omitempty"`
+	Verrechnet   *bool            `json:"Verrechnet,omitempty"`
+	ErstelltAm   *pxtime.DateTime `json:"ErstelltAm,omitempty"`
+	ErstelltVon  string           `json:"ErstelltVon,omitempty"`
+	GeaendertAm  *pxtime.DateTime `json:"GeaendertAm,omitempty"`
+	GeaendertVon string           `json:"GeaendertVon,omitempty"`
+}
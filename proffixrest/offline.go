@@ -0,0 +1,394 @@
+package proffixrest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OfflineQueueHeader is set in the header returned for a write which was queued instead of sent
+const OfflineQueueHeader = "X-Offline-Queue-Id"
+
+// States of an OfflineEntry
+const (
+	OfflinePending = "pending" // Waiting to be replayed
+	OfflineFailed  = "failed"  // Rejected by PROFFIX; kept until it is retried or discarded
+)
+
+// ConflictAction decides how a conflicting entry is handled during replay
+type ConflictAction int
+
+// Actions of OfflineOptions.OnConflict
+const (
+	ConflictFail ConflictAction = iota // Mark the entry as failed and keep it (default)
+	ConflictDrop                       // Remove the entry from the queue
+)
+
+// OfflineEntry is a queued write, stored as file in the queue directory
+type OfflineEntry struct {
+	ID         string          `json:"ID"`
+	Method     string          `json:"Method"`
+	Endpoint   string          `json:"Endpoint"`
+	Data       json.RawMessage `json:"Data,omitempty"`
+	Queued     time.Time       `json:"Queued"`
+	State      string          `json:"State"`
+	Attempts   int             `json:"Attempts"`
+	LastStatus int             `json:"LastStatus,omitempty"`
+	LastError  string          `json:"LastError,omitempty"`
+
+	file string
+}
+
+// OfflineOptions configures an OfflineQueue
+type OfflineOptions struct {
+	Dir      string        // Directory of the persistent queue (required)
+	Interval time.Duration // Interval in which Run replays the queue. Default is 30 seconds
+	// OnConflict decides about entries answered with 404 (e.g. entry deleted meanwhile) or 409 (e.g. duplicate).
+	// A DELETE answered with 404 is always done. Default is ConflictFail
+	OnConflict func(entry OfflineEntry, err *PxError) ConflictAction
+}
+
+// OfflineStatus lists the entries of an OfflineQueue
+type OfflineStatus struct {
+	Pending int
+	Failed  int
+	Entries []OfflineEntry // All entries in queue order
+}
+
+// OfflineReplayResult counts the entries processed by a Replay
+type OfflineReplayResult struct {
+	Sent    int // Sent successfully and removed
+	Failed  int // Rejected and marked as failed
+	Dropped int // Removed by OnConflict
+	Pending int // Still waiting because PROFFIX is unreachable
+}
+
+// OfflineQueue sends writes to PROFFIX or, while the server is unreachable, stores them on disk and replays them in order later.
+// The server counts as unreachable on transport errors and on status 502, 503 and 504.
+// As long as entries are pending, new writes are queued as well so the order is kept.
+//
+// A write whose response was lost (e.g. the connection dropped after the request was sent) is replayed,
+// so a POST can be created twice; use OnConflict or a unique field to detect this.
+type OfflineQueue struct {
+	client *Client
+	opts   OfflineOptions
+	mu     sync.Mutex
+	seq    int64
+}
+
+// NewOfflineQueue creates an OfflineQueue and its directory. Entries of an earlier run are kept.
+func (c *Client) NewOfflineQueue(opts *OfflineOptions) (*OfflineQueue, error) {
+	q := &OfflineQueue{client: c}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.Dir == "" {
+		return nil, &PxError{Message: "OfflineOptions.Dir is required"}
+	}
+	if q.opts.Interval <= 0 {
+		q.opts.Interval = 30 * time.Second
+	}
+	if err := os.MkdirAll(q.opts.Dir, 0o750); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Post sends a POST request or queues it while PROFFIX is unreachable.
+// A queued write returns status 202 without error and its entry ID in the OfflineQueueHeader.
+func (q *OfflineQueue) Post(ctx context.Context, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	return q.write(ctx, http.MethodPost, endpoint, data)
+}
+
+// Put sends a PUT request or queues it while PROFFIX is unreachable.
+func (q *OfflineQueue) Put(ctx context.Context, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	return q.write(ctx, http.MethodPut, endpoint, data)
+}
+
+// Patch sends a PATCH request or queues it while PROFFIX is unreachable.
+func (q *OfflineQueue) Patch(ctx context.Context, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	return q.write(ctx, http.MethodPatch, endpoint, data)
+}
+
+// Delete sends a DELETE request or queues it while PROFFIX is unreachable.
+func (q *OfflineQueue) Delete(ctx context.Context, endpoint string) (io.ReadCloser, http.Header, int, error) {
+	return q.write(ctx, http.MethodDelete, endpoint, nil)
+}
+
+// write sends or queues a write
+func (q *OfflineQueue) write(ctx context.Context, method string, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	var raw json.RawMessage
+	if data != nil {
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return nil, nil, 0, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("JSON Encoding failed: %s", err)}
+		}
+	}
+
+	q.mu.Lock()
+	entries, err := q.entries()
+	if err != nil {
+		q.mu.Unlock()
+		return nil, nil, 0, err
+	}
+	for _, entry := range entries {
+		if entry.State == OfflinePending {
+			defer q.mu.Unlock()
+			return q.enqueue(method, endpoint, raw)
+		}
+	}
+	// The lock is not held while sending, so a slow request does not block other writes
+	q.mu.Unlock()
+
+	rc, header, status, err := q.client.send(ctx, method, endpoint, raw)
+	if err != nil && unreachable(ctx, status, err) {
+		logDebug(ctx, q.client, fmt.Sprintf("PROFFIX unreachable, queueing %s %s: %v", method, endpoint, err))
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.enqueue(method, endpoint, raw)
+	}
+	return rc, header, status, err
+}
+
+// enqueue stores a write in the queue
+func (q *OfflineQueue) enqueue(method string, endpoint string, data json.RawMessage) (io.ReadCloser, http.Header, int, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	q.seq++
+	entry := &OfflineEntry{ID: id, Method: method, Endpoint: endpoint, Data: data, Queued: time.Now().UTC(), State: OfflinePending}
+	entry.file = filepath.Join(q.opts.Dir, outboxFileName(q.seq))
+	if err := q.save(entry); err != nil {
+		return nil, nil, 0, err
+	}
+
+	header := http.Header{}
+	header.Set(OfflineQueueHeader, id)
+	return nil, header, http.StatusAccepted, nil
+}
+
+// Replay sends the pending entries in order. It stops at the first entry which fails because PROFFIX is unreachable
+// and returns that error; entries rejected by PROFFIX are marked as failed and the replay continues.
+func (q *OfflineQueue) Replay(ctx context.Context) (OfflineReplayResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var res OfflineReplayResult
+	entries, err := q.entries()
+	if err != nil {
+		return res, err
+	}
+
+	for i, entry := range entries {
+		if entry.State != OfflinePending {
+			continue
+		}
+
+		rc, _, status, sendErr := q.client.send(ctx, entry.Method, entry.Endpoint, entry.Data)
+		closeBody(rc)
+		entry.Attempts++
+		entry.LastStatus = status
+
+		if sendErr != nil && unreachable(ctx, status, sendErr) {
+			entry.LastError = sendErr.Error()
+			if err := q.save(entry); err != nil {
+				return res, err
+			}
+			for _, rest := range entries[i:] {
+				if rest.State == OfflinePending {
+					res.Pending++
+				}
+			}
+			return res, sendErr
+		}
+
+		// An entry which is already gone counts as deleted
+		if sendErr == nil || (entry.Method == http.MethodDelete && status == http.StatusNotFound) {
+			if err := q.remove(entry); err != nil {
+				return res, err
+			}
+			res.Sent++
+			continue
+		}
+
+		pxErr := toPxError(sendErr, entry.Endpoint)
+		if (status == http.StatusNotFound || status == http.StatusConflict) && q.opts.OnConflict != nil &&
+			q.opts.OnConflict(*entry, pxErr) == ConflictDrop {
+			if err := q.remove(entry); err != nil {
+				return res, err
+			}
+			res.Dropped++
+			continue
+		}
+
+		entry.State = OfflineFailed
+		entry.LastError = pxErr.Error()
+		if err := q.save(entry); err != nil {
+			return res, err
+		}
+		res.Failed++
+	}
+	return res, nil
+}
+
+// Run replays the queue immediately and then every Interval until ctx is done.
+func (q *OfflineQueue) Run(ctx context.Context) error {
+	ticker := time.NewTicker(q.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = q.Replay(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns all entries of the queue
+func (q *OfflineQueue) Status() (OfflineStatus, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var status OfflineStatus
+	entries, err := q.entries()
+	if err != nil {
+		return status, err
+	}
+	for _, entry := range entries {
+		if entry.State == OfflineFailed {
+			status.Failed++
+		} else {
+			status.Pending++
+		}
+		status.Entries = append(status.Entries, *entry)
+	}
+	return status, nil
+}
+
+// Retry marks a failed entry as pending again; it is replayed at its original position.
+func (q *OfflineQueue) Retry(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.find(id)
+	if err != nil {
+		return err
+	}
+	entry.State = OfflinePending
+	return q.save(entry)
+}
+
+// Discard removes an entry from the queue
+func (q *OfflineQueue) Discard(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.find(id)
+	if err != nil {
+		return err
+	}
+	return q.remove(entry)
+}
+
+// find returns the entry with id
+func (q *OfflineQueue) find(id string) (*OfflineEntry, error) {
+	entries, err := q.entries()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return nil, &PxError{Type: "NOT_FOUND", Message: fmt.Sprintf("Offline entry %s not found", id)}
+}
+
+// entries reads all entries of the queue in order
+func (q *OfflineQueue) entries() ([]*OfflineEntry, error) {
+	var entries []*OfflineEntry
+	err := readOutbox(q.opts.Dir, func(path string, data []byte) error {
+		var entry OfflineEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("invalid queue file %s: %w", path, err)
+		}
+		entry.file = path
+		entries = append(entries, &entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// save writes an entry to its file
+func (q *OfflineQueue) save(entry *OfflineEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(entry.file, data, 0o600)
+}
+
+// remove deletes the file of an entry
+func (q *OfflineQueue) remove(entry *OfflineEntry) error {
+	if err := os.Remove(entry.file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// send sends a write with an already encoded body
+func (c *Client) send(ctx context.Context, method string, endpoint string, data json.RawMessage) (io.ReadCloser, http.Header, int, error) {
+	var body interface{}
+	if data != nil {
+		body = data
+	}
+	switch method {
+	case http.MethodPost:
+		return c.Post(ctx, endpoint, body)
+	case http.MethodPut:
+		return c.Put(ctx, endpoint, body)
+	case http.MethodPatch:
+		return c.Patch(ctx, endpoint, body)
+	case http.MethodDelete:
+		return c.Delete(ctx, endpoint)
+	}
+	return nil, nil, 0, &PxError{Message: fmt.Sprintf("Method is not recognised: %s", method)}
+}
+
+// unreachable checks if a write failed because the server could not be reached: a network error or status 502, 503 or 504.
+// Cancellations and deadlines of the caller as well as local errors (e.g. JSON encoding) are not queued but returned.
+// Errors of the login carry their status only in the PxError.
+func unreachable(ctx context.Context, status int, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pxErr *PxError
+	if status == 0 && errors.As(err, &pxErr) {
+		status = pxErr.Status
+	}
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case 0:
+		var netErr net.Error
+		var urlErr *url.Error
+		return errors.As(err, &netErr) || errors.As(err, &urlErr)
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+package proffixrest
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Modes of newOfflineFake
+const (
+	fakeOnline      int32 = iota
+	fakeDropped           // Connections are closed without response
+	fakeUnavailable       // A proxy answers with 503
+)
+
+// newOfflineFake returns a fake server which is unreachable according to the returned mode
+func newOfflineFake(t *testing.T) (*fakePX, *atomic.Int32) {
+	t.Helper()
+	px := newFakePX(t, map[string]string{"STU/Rapporte": "RapportNr", "ADR/Adresse": "AdressNr"})
+	mode := &atomic.Int32{}
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		switch mode.Load() {
+		case fakeOnline:
+			return false
+		case fakeUnavailable:
+			writePxError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "maintenance")
+			return true
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("Hijack failed: %v", err)
+		}
+		_ = conn.Close()
+		return true
+	}
+	return px, mode
+}
+
+func TestOfflineQueue_QueuesAndReplaysInOrder(t *testing.T) {
+	px, mode := newOfflineFake(t)
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": "1", "Name": "Muster AG"})
+	ctx := context.Background()
+
+	q, err := px.client(nil).NewOfflineQueue(&OfflineOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Online writes are sent directly
+	_, header, status, err := q.Post(ctx, "STU/Rapporte", map[string]interface{}{"RapportNr": "R1"})
+	if err != nil || status != http.StatusCreated || header.Get(OfflineQueueHeader) != "" {
+		t.Fatalf("Expected direct POST with 201, got %d %v", status, err)
+	}
+
+	mode.Store(fakeDropped)
+	_, header, status, err = q.Post(ctx, "STU/Rapporte", map[string]interface{}{"RapportNr": "R2"})
+	if err != nil || status != http.StatusAccepted || header.Get(OfflineQueueHeader) == "" {
+		t.Fatalf("Expected queued POST with 202, got %d %v", status, err)
+	}
+	_, _, _, _ = q.Patch(ctx, "ADR/Adresse/1", map[string]interface{}{"Name": "Neu AG"})
+
+	// Writes stay queued behind pending entries even when the server is back
+	mode.Store(fakeOnline)
+	_, _, status, _ = q.Delete(ctx, "STU/Rapporte/R1")
+	if status != http.StatusAccepted {
+		t.Errorf("Expected DELETE queued behind pending entries, got %d", status)
+	}
+
+	st, err := q.Status()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if st.Pending != 3 || st.Failed != 0 || len(st.Entries) != 3 {
+		t.Fatalf("Expected 3 pending entries, got %+v", st)
+	}
+	if st.Entries[0].Method != http.MethodPost || st.Entries[1].Method != http.MethodPatch || st.Entries[2].Method != http.MethodDelete {
+		t.Errorf("Expected entries in write order, got %+v", st.Entries)
+	}
+
+	writes := func() int {
+		return px.callCount("POST", "STU/") + px.callCount("PATCH", "ADR/") + px.callCount("DELETE", "STU/")
+	}
+	calls := writes()
+	res, err := q.Replay(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Sent != 3 || res.Pending != 0 {
+		t.Errorf("Expected 3 sent, got %+v", res)
+	}
+	if writes()-calls != 3 {
+		t.Errorf("Expected 3 requests during replay, got %d", writes()-calls)
+	}
+	if _, ok := px.get("STU/Rapporte", "R2"); !ok {
+		t.Errorf("Expected R2 to be created")
+	}
+	if _, ok := px.get("STU/Rapporte", "R1"); ok {
+		t.Errorf("Expected R1 to be deleted")
+	}
+	if rec, _ := px.get("ADR/Adresse", "1"); rec["Name"] != "Neu AG" {
+		t.Errorf("Expected patched name, got %v", rec["Name"])
+	}
+	if st, _ := q.Status(); len(st.Entries) != 0 {
+		t.Errorf("Expected empty queue, got %+v", st)
+	}
+}
+
+func TestOfflineQueue_ReplayStopsWhileUnreachable(t *testing.T) {
+	px, mode := newOfflineFake(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	q, _ := px.client(nil).NewOfflineQueue(&OfflineOptions{Dir: dir})
+	mode.Store(fakeDropped)
+	_, _, _, _ = q.Post(ctx, "STU/Rapporte", map[string]interface{}{"RapportNr": "R1"})
+	_, _, _, _ = q.Post(ctx, "STU/Rapporte", map[string]interface{}{"RapportNr": "R2"})
+
+	res, err := q.Replay(ctx)
+	if err == nil {
+		t.Errorf("Expected error while unreachable")
+	}
+	if res.Sent != 0 || res.Pending != 2 {
+		t.Errorf("Expected 2 pending, got %+v", res)
+	}
+	st, _ := q.Status()
+	if st.Entries[0].Attempts != 1 || st.Entries[0].LastError == "" || st.Entries[1].Attempts != 0 {
+		t.Errorf("Expected only the first entry attempted, got %+v", st.Entries)
+	}
+
+	// 503 of a proxy counts as unreachable as well
+	mode.Store(fakeUnavailable)
+	if res, _ := q.Replay(ctx); res.Pending != 2 || res.Failed != 0 {
+		t.Errorf("Expected entries to stay pending on 503, got %+v", res)
+	}
+
+	// Entries survive a restart
+	mode.Store(fakeOnline)
+	restarted, _ := px.client(nil).NewOfflineQueue(&OfflineOptions{Dir: dir})
+	if res, err := restarted.Replay(ctx); err != nil || res.Sent != 2 {
+		t.Errorf("Expected 2 sent after restart, got %+v %v", res, err)
+	}
+}
+
+func TestOfflineQueue_Conflicts(t *testing.T) {
+	px, mode := newOfflineFake(t)
+	px.put("STU/Rapporte", map[string]interface{}{"RapportNr": "R1"})
+	ctx := context.Background()
+
+	var conflicts []int
+	q, _ := px.client(nil).NewOfflineQueue(&OfflineOptions{
+		Dir: t.TempDir(),
+		OnConflict: func(entry OfflineEntry, err *PxError) ConflictAction {
+			conflicts = append(conflicts, err.Status)
+			if err.Status == http.StatusConflict {
+				return ConflictDrop
+			}
+			return ConflictFail
+		},
+	})
+
+	mode.Store(fakeDropped)
+	_, _, _, _ = q.Post(ctx, "STU/Rapporte", map[string]interface{}{"RapportNr": "R1"}) // 409
+	_, _, _, _ = q.Patch(ctx, "ADR/Adresse/9", map[string]interface{}{"Name": "X"})     // 404
+	_, _, _, _ = q.Delete(ctx, "ADR/Adresse/8")                                         // 404 counts as deleted
+	_, _, _, _ = q.Post(ctx, "STU/Rapporte", map[string]interface{}{"RapportNr": "R2"}) // ok
+	_, _, _, _ = q.Post(ctx, "STU/Rapporte", map[string]interface{}{"Unbekannt": "x"})  // ok
+	mode.Store(fakeOnline)
+
+	res, err := q.Replay(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Sent != 3 || res.Dropped != 1 || res.Failed != 1 {
+		t.Errorf("Expected 3 sent, 1 dropped and 1 failed, got %+v", res)
+	}
+	if len(conflicts) != 2 || conflicts[0] != http.StatusConflict || conflicts[1] != http.StatusNotFound {
+		t.Errorf("Expected conflicts 409 and 404, got %v", conflicts)
+	}
+
+	st, _ := q.Status()
+	if st.Failed != 1 || st.Pending != 0 || st.Entries[0].Endpoint != "ADR/Adresse/9" || st.Entries[0].LastStatus != http.StatusNotFound {
+		t.Fatalf("Expected failed PATCH in status, got %+v", st)
+	}
+
+	// Failed entries are not replayed until retried
+	if res, _ := q.Replay(ctx); res.Sent != 0 || res.Failed != 0 {
+		t.Errorf("Expected nothing replayed, got %+v", res)
+	}
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": "9"})
+	if err := q.Retry(st.Entries[0].ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res, _ := q.Replay(ctx); res.Sent != 1 {
+		t.Errorf("Expected retried entry to be sent, got %+v", res)
+	}
+	if err := q.Discard(st.Entries[0].ID); err == nil {
+		t.Errorf("Expected error when discarding a sent entry")
+	}
+}
+
+func TestOfflineQueue_ReturnsRejectedWrites(t *testing.T) {
+	px, _ := newOfflineFake(t)
+	q, _ := px.client(nil).NewOfflineQueue(&OfflineOptions{Dir: t.TempDir()})
+
+	_, _, status, err := q.Patch(context.Background(), "ADR/Adresse/404", map[string]interface{}{"Name": "X"})
+	if err == nil || status != http.StatusNotFound {
+		t.Errorf("Expected 404 error for online write, got %d %v", status, err)
+	}
+	if st, _ := q.Status(); len(st.Entries) != 0 {
+		t.Errorf("Expected rejected write not to be queued, got %+v", st)
+	}
+
+	if _, err := px.client(nil).NewOfflineQueue(nil); err == nil {
+		t.Errorf("Expected error without Dir")
+	}
+}
+
+func TestOfflineQueue_DoesNotQueueLocalErrors(t *testing.T) {
+	px, _ := newOfflineFake(t)
+	q, _ := px.client(nil).NewOfflineQueue(&OfflineOptions{Dir: t.TempDir()})
+
+	// A value which cannot be encoded as JSON
+	if _, _, _, err := q.Post(context.Background(), "STU/Rapporte", map[string]interface{}{"X": make(chan int)}); err == nil {
+		t.Errorf("Expected encoding error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, status, err := q.Post(ctx, "STU/Rapporte", map[string]interface{}{"Text": "a"}); err == nil || status == http.StatusAccepted {
+		t.Errorf("Expected cancellation to be returned, got %d %v", status, err)
+	}
+	if st, _ := q.Status(); len(st.Entries) != 0 {
+		t.Errorf("Expected nothing to be queued, got %+v", st)
+	}
+}
+
+func TestOfflineQueue_SlowWriteDoesNotBlock(t *testing.T) {
+	px, _ := newOfflineFake(t)
+	release := make(chan struct{})
+	defer close(release)
+	online := px.handler
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint == "ADR/Adresse/langsam" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return true
+		}
+		return online(w, r, endpoint)
+	}
+	c := px.client(nil)
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	q, _ := c.NewOfflineQueue(&OfflineOptions{Dir: t.TempDir()})
+
+	go func() {
+		_, _, _, _ = q.Patch(context.Background(), "ADR/Adresse/langsam", map[string]interface{}{"Name": "X"})
+	}()
+	for px.callCount("PATCH", "ADR/Adresse/langsam") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		rc, _, _, err := q.Post(context.Background(), "STU/Rapporte", map[string]interface{}{"Text": "a"})
+		closeBody(rc)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected write not to wait for the slow request")
+	}
+}
+
+func TestClient_TransportErrorKeepsSession(t *testing.T) {
+	px, mode := newOfflineFake(t)
+	c := px.client(nil)
+	ctx := context.Background()
+
+	if err := c.Login(ctx); err != nil || c.GetPxSessionID() != "session-1" {
+		t.Fatalf("Expected login, got %v", err)
+	}
+
+	mode.Store(fakeDropped)
+	if _, _, _, err := c.Get(ctx, "ADR/Adresse", nil); err == nil {
+		t.Fatalf("Expected transport error")
+	}
+	if c.GetPxSessionID() != "session-1" {
+		t.Errorf("Expected session to be kept, got %q", c.GetPxSessionID())
+	}
+	if n := px.callCount("DELETE", ""); n != 0 {
+		t.Errorf("Expected no logout request, got %d", n)
+	}
+
+	mode.Store(fakeOnline)
+	rc, _, _, err := c.Get(ctx, "ADR/Adresse", nil)
+	closeBody(rc)
+	if err != nil || c.GetPxSessionID() != "session-1" {
+		t.Errorf("Expected request with the same session after reconnect, got %v", err)
+	}
+	if n := px.loginCount(); n != 1 {
+		t.Errorf("Expected no new login after reconnect, got %d logins", n)
+	}
+}
@@ -18,7 +18,10 @@ type Options struct {
 	Batchsize     int
 	Log           bool
 	Autologout    bool
-	VolumeLicence bool         // If API should use Volume Licencing
-	HTTPClient    *http.Client // Optional custom HTTP client to use
-	Logger        *log.Logger  // Optional logger; overrides Log flag when provided
+	VolumeLicence bool          // If API should use Volume Licencing
+	HTTPClient    *http.Client  // Optional custom HTTP client to use
+	Logger        *log.Logger   // Optional logger; overrides Log flag when provided
+	Cache         *CacheOptions // Optional read-through cache of Get
+	DedupeGets    bool          // Collapses identical concurrent GET requests into a single request
+	ListCacheTTL  time.Duration // Duration the catalogue of PRO/Liste is cached. Default is 10 minutes
 }
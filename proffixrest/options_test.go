@@ -41,6 +41,11 @@ func TestOptions_DefaultValues(t *testing.T) {
 		t.Errorf("Expected default Batchsize 200, got %d", client.option.Batchsize)
 	}
 
+	// Check default ListCacheTTL
+	if client.option.ListCacheTTL != 10*time.Minute {
+		t.Errorf("Expected default ListCacheTTL 10m, got %v", client.option.ListCacheTTL)
+	}
+
 	// Check UserAgent contains wrapper name
 	if client.option.UserAgent == "" {
 		t.Errorf("Expected non-empty UserAgent")
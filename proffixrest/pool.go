@@ -0,0 +1,35 @@
+package proffixrest
+
+import "sync"
+
+// parallel runs fn for every job received from jobs with the given number of workers
+// and returns once jobs is closed and all jobs are done
+func parallel[J any](workers int, jobs <-chan J, fn func(J)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				fn(job)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// parallelN runs fn for the indices 0..n-1 with the given number of workers
+func parallelN(workers int, n int, fn func(i int)) {
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < n; i++ {
+			jobs <- i
+		}
+	}()
+	parallel(workers, jobs, fn)
+}
@@ -0,0 +1,39 @@
+package proffixrest
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelN(t *testing.T) {
+	results := make([]int, 100)
+	var calls int32
+
+	parallelN(8, len(results), func(i int) {
+		atomic.AddInt32(&calls, 1)
+		results[i] = i * 2
+	})
+
+	if calls != 100 {
+		t.Errorf("Expected 100 calls, got %v", calls)
+	}
+	for i, r := range results {
+		if r != i*2 {
+			t.Fatalf("Expected results[%d] = %d, got %d", i, i*2, r)
+		}
+	}
+}
+
+func TestParallel_MinimumOneWorker(t *testing.T) {
+	jobs := make(chan int, 3)
+	jobs <- 1
+	jobs <- 2
+	jobs <- 3
+	close(jobs)
+
+	sum := 0
+	parallel(0, jobs, func(j int) { sum += j })
+	if sum != 6 {
+		t.Errorf("Expected sum 6, got %v", sum)
+	}
+}
@@ -0,0 +1,175 @@
+// Package pxtime provides JSON types and conversions for timestamps and dates of the PROFFIX REST-API.
+//
+// PROFFIX sends timestamps without zone information in local Swiss time. Values are therefore parsed and
+// formatted in a configurable location which defaults to Europe/Zurich. The time zone database is embedded,
+// so this also works on systems without zoneinfo (e.g. scratch containers).
+package pxtime
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	// Embed the time zone database for Europe/Zurich
+	_ "time/tzdata"
+)
+
+// Layouts used by the PROFFIX REST-API.
+const (
+	DateTimeLayout = "2006-01-02 15:04:05"
+	DateLayout     = "2006-01-02"
+)
+
+var (
+	mu       sync.RWMutex
+	location = mustLoadLocation("Europe/Zurich")
+)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Location returns the location used for parsing and formatting. Default is Europe/Zurich.
+func Location() *time.Location {
+	mu.RLock()
+	defer mu.RUnlock()
+	return location
+}
+
+// SetLocation changes the location used for parsing and formatting. A nil location resets it to Europe/Zurich.
+func SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = mustLoadLocation("Europe/Zurich")
+	}
+	mu.Lock()
+	location = loc
+	mu.Unlock()
+}
+
+// Parse parses a PROFFIX timestamp (2006-01-02 15:04:05) in the configured location.
+func Parse(s string) (time.Time, error) {
+	t, err := time.ParseInLocation(DateTimeLayout, s, Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("pxtime: invalid timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// ParseDate parses a PROFFIX date (2006-01-02) in the configured location.
+// Timestamps are accepted as well and truncated to midnight, as PROFFIX returns some dates with time 00:00:00.
+func ParseDate(s string) (time.Time, error) {
+	loc := Location()
+	t, err := time.ParseInLocation(DateLayout, s, loc)
+	if err == nil {
+		return t, nil
+	}
+	if ts, errTS := time.ParseInLocation(DateTimeLayout, s, loc); errTS == nil {
+		return time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, loc), nil
+	}
+	return time.Time{}, fmt.Errorf("pxtime: invalid date %q: %w", s, err)
+}
+
+// Format formats t as PROFFIX timestamp after converting it to the configured location.
+func Format(t time.Time) string {
+	return t.In(Location()).Format(DateTimeLayout)
+}
+
+// FormatDate formats t as PROFFIX date after converting it to the configured location.
+func FormatDate(t time.Time) string {
+	return t.In(Location()).Format(DateLayout)
+}
+
+// DateTime is a PROFFIX timestamp (e.g. ErstelltAm). Null and empty strings decode to the zero value,
+// the zero value encodes to null.
+type DateTime struct {
+	time.Time
+}
+
+// NewDateTime wraps t as DateTime.
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{Time: t}
+}
+
+// MarshalJSON encodes the timestamp in the PROFFIX layout.
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + Format(d.Time) + `"`), nil
+}
+
+// UnmarshalJSON decodes a PROFFIX timestamp.
+func (d *DateTime) UnmarshalJSON(b []byte) error {
+	s, ok, err := unquote(b)
+	if err != nil || !ok {
+		d.Time = time.Time{}
+		return err
+	}
+	d.Time, err = Parse(s)
+	return err
+}
+
+// String returns the timestamp in the PROFFIX layout or an empty string for the zero value.
+func (d DateTime) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return Format(d.Time)
+}
+
+// Date is a PROFFIX date without time of day (e.g. Geburtsdatum). Null and empty strings decode to the
+// zero value, the zero value encodes to null. The calendar day of the value is used as is, without
+// converting it to the configured location.
+type Date struct {
+	time.Time
+}
+
+// NewDate wraps the calendar day of t, as seen in the location of t, as Date.
+func NewDate(t time.Time) Date {
+	return Date{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Location())}
+}
+
+// MarshalJSON encodes the date in the PROFFIX layout.
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.Time.Format(DateLayout) + `"`), nil
+}
+
+// UnmarshalJSON decodes a PROFFIX date.
+func (d *Date) UnmarshalJSON(b []byte) error {
+	s, ok, err := unquote(b)
+	if err != nil || !ok {
+		d.Time = time.Time{}
+		return err
+	}
+	d.Time, err = ParseDate(s)
+	return err
+}
+
+// String returns the date in the PROFFIX layout or an empty string for the zero value.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Time.Format(DateLayout)
+}
+
+// unquote returns the content of a JSON string; ok is false for null and empty strings
+func unquote(b []byte) (s string, ok bool, err error) {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return "", false, nil
+	}
+	if len(b) < 2 || b[0] != '"' || b[len(b)-1] != '"' {
+		return "", false, fmt.Errorf("pxtime: expected JSON string, got %s", b)
+	}
+	s = string(b[1 : len(b)-1])
+	return s, s != "", nil
+}
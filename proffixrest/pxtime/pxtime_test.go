@@ -0,0 +1,121 @@
+package pxtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type record struct {
+	GeaendertAm  DateTime  `json:"GeaendertAm"`
+	ErstelltAm   *DateTime `json:"ErstelltAm,omitempty"`
+	Geburtsdatum Date      `json:"Geburtsdatum"`
+}
+
+func TestDateTime_Unmarshal(t *testing.T) {
+	var r record
+	err := json.Unmarshal([]byte(`{"GeaendertAm":"2024-07-01 12:30:00","ErstelltAm":null,"Geburtsdatum":"1980-05-17"}`), &r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 12:30 in Zurich summer time is 10:30 UTC
+	want := time.Date(2024, 7, 1, 10, 30, 0, 0, time.UTC)
+	if !r.GeaendertAm.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, r.GeaendertAm.UTC())
+	}
+	if r.GeaendertAm.Location().String() != "Europe/Zurich" {
+		t.Errorf("Expected location Europe/Zurich, got %v", r.GeaendertAm.Location())
+	}
+	if r.ErstelltAm != nil {
+		t.Errorf("Expected ErstelltAm nil, got %v", r.ErstelltAm)
+	}
+	if r.Geburtsdatum.Year() != 1980 || r.Geburtsdatum.Month() != 5 || r.Geburtsdatum.Day() != 17 {
+		t.Errorf("Expected 1980-05-17, got %v", r.Geburtsdatum)
+	}
+}
+
+func TestDateTime_NullAndEmpty(t *testing.T) {
+	var r record
+	err := json.Unmarshal([]byte(`{"GeaendertAm":"","Geburtsdatum":null}`), &r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !r.GeaendertAm.IsZero() || !r.Geburtsdatum.IsZero() {
+		t.Errorf("Expected zero values, got %v and %v", r.GeaendertAm, r.Geburtsdatum)
+	}
+
+	out, _ := json.Marshal(r)
+	if string(out) != `{"GeaendertAm":null,"Geburtsdatum":null}` {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+}
+
+func TestDateTime_Marshal(t *testing.T) {
+	r := record{
+		GeaendertAm:  NewDateTime(time.Date(2024, 1, 15, 7, 0, 0, 0, time.UTC)),
+		Geburtsdatum: NewDate(time.Date(1980, 5, 17, 0, 0, 0, 0, Location())),
+	}
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// 07:00 UTC is 08:00 in Zurich winter time
+	if string(out) != `{"GeaendertAm":"2024-01-15 08:00:00","Geburtsdatum":"1980-05-17"}` {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+}
+
+func TestDate_MarshalOtherLocation(t *testing.T) {
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+	midnight := time.Date(2024, 5, 17, 0, 0, 0, 0, tokyo)
+
+	// Midnight in Tokyo is the previous day in Zurich, but a Date keeps its calendar day
+	for _, d := range []Date{{Time: midnight}, NewDate(midnight)} {
+		out, err := json.Marshal(d)
+		if err != nil || string(out) != `"2024-05-17"` || d.String() != "2024-05-17" {
+			t.Errorf("Expected 2024-05-17, got %s (%v)", out, err)
+		}
+	}
+}
+
+func TestDateTime_Invalid(t *testing.T) {
+	var d DateTime
+	if err := json.Unmarshal([]byte(`"15.01.2024"`), &d); err == nil {
+		t.Errorf("Expected error for invalid timestamp")
+	}
+	if err := json.Unmarshal([]byte(`12345`), &d); err == nil {
+		t.Errorf("Expected error for number")
+	}
+	if _, err := ParseDate("2024-13-01"); err == nil {
+		t.Errorf("Expected error for invalid date")
+	}
+}
+
+func TestParseDate_Timestamp(t *testing.T) {
+	d, err := ParseDate("2024-03-01 00:00:00")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if FormatDate(d) != "2024-03-01" {
+		t.Errorf("Expected 2024-03-01, got %v", FormatDate(d))
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	defer SetLocation(nil)
+
+	SetLocation(time.UTC)
+	ts, err := Parse("2024-07-01 12:30:00")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ts.Hour() != 12 || ts.Location() != time.UTC {
+		t.Errorf("Expected 12:30 UTC, got %v", ts)
+	}
+
+	SetLocation(nil)
+	if Location().String() != "Europe/Zurich" {
+		t.Errorf("Expected location reset to Europe/Zurich, got %v", Location())
+	}
+}
@@ -0,0 +1,138 @@
+package proffixrest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Resource binds a Go type to an endpoint of the PROFFIX REST-API and provides typed CRUD methods.
+// It is built on the methods of Client, so login, logging and errors behave the same way.
+type Resource[T any] struct {
+	client   *Client
+	endpoint string
+}
+
+// NewResource creates a typed Resource for the given endpoint (e.g. "ADR/Adresse").
+func NewResource[T any](c *Client, endpoint string) *Resource[T] {
+	return &Resource[T]{client: c, endpoint: endpoint}
+}
+
+// Endpoint returns the endpoint the resource is bound to.
+func (r *Resource[T]) Endpoint() string {
+	return r.endpoint
+}
+
+// Get fetches a single entry by its key.
+func (r *Resource[T]) Get(ctx context.Context, key interface{}) (*T, error) {
+	rc, _, _, err := r.client.Get(ctx, r.keyEndpoint(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	item := new(T)
+	if err := decodeBody(rc, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// List fetches a single page of entries. Paging is controlled with the params Limit and Offset.
+// Returns the entries and the total available entries reported by PROFFIX.
+func (r *Resource[T]) List(ctx context.Context, params url.Values) (items []T, total int, err error) {
+	rc, header, _, err := r.client.Get(ctx, r.endpoint, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := decodeBody(rc, &items); err != nil {
+		return nil, 0, err
+	}
+	return items, GetFilteredCount(header), nil
+}
+
+// All fetches all entries matching params by paginating with GetBatch.
+func (r *Resource[T]) All(ctx context.Context, params url.Values) (items []T, err error) {
+	res, _, err := r.client.GetBatch(ctx, r.endpoint, params, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return []T{}, nil
+	}
+	if err := json.Unmarshal(res, &items); err != nil {
+		return nil, &PxError{Endpoint: r.endpoint, Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+	}
+	return items, nil
+}
+
+// Create creates a new entry (POST) and returns its key taken from the Location header.
+func (r *Resource[T]) Create(ctx context.Context, item *T) (id string, err error) {
+	rc, header, _, err := r.client.Post(ctx, r.endpoint, item)
+	closeBody(rc)
+	if err != nil {
+		return "", err
+	}
+	return ConvertLocationToID(header), nil
+}
+
+// Update overwrites an existing entry (PUT).
+func (r *Resource[T]) Update(ctx context.Context, key interface{}, item *T) error {
+	rc, _, _, err := r.client.Put(ctx, r.keyEndpoint(key), item)
+	closeBody(rc)
+	return err
+}
+
+// Save sends only the fields changed between original and modified as PATCH (see ChangeSet).
+// Concurrent changes of other fields, e.g. in the PROFFIX UI, are not overwritten.
+// If nothing changed no request is sent and changed is false.
+func (r *Resource[T]) Save(ctx context.Context, key interface{}, original *T, modified *T) (changed bool, err error) {
+	changes, err := ChangeSet(original, modified)
+	if err != nil {
+		return false, err
+	}
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	rc, _, _, err := r.client.Patch(ctx, r.keyEndpoint(key), changes)
+	closeBody(rc)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete deletes an entry by its key.
+func (r *Resource[T]) Delete(ctx context.Context, key interface{}) error {
+	rc, _, _, err := r.client.Delete(ctx, r.keyEndpoint(key))
+	closeBody(rc)
+	return err
+}
+
+// keyEndpoint builds the endpoint of a single entry
+func (r *Resource[T]) keyEndpoint(key interface{}) string {
+	return r.endpoint + "/" + url.PathEscape(fmt.Sprintf("%v", key))
+}
+
+// decodeBody decodes a JSON response into v and closes the body
+func decodeBody(rc io.ReadCloser, v interface{}) error {
+	if rc == nil {
+		return &PxError{Message: "empty response body"}
+	}
+	defer func() { _ = rc.Close() }()
+
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return &PxError{Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+	}
+	return nil
+}
+
+// closeBody closes a response body if there is one
+func closeBody(rc io.ReadCloser) {
+	if rc != nil {
+		_ = rc.Close()
+	}
+}
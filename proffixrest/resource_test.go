@@ -0,0 +1,136 @@
+package proffixrest
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+type testArtikel struct {
+	ArtikelNr    string  `json:"ArtikelNr,omitempty"`
+	Bezeichnung1 string  `json:"Bezeichnung1,omitempty"`
+	Gewicht      float64 `json:"Gewicht,omitempty"`
+}
+
+func TestResource_CRUD(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	res := NewResource[testArtikel](px.client(nil), "LAG/Artikel")
+
+	if res.Endpoint() != "LAG/Artikel" {
+		t.Errorf("Expected endpoint 'LAG/Artikel', got '%s'", res.Endpoint())
+	}
+
+	id, err := res.Create(ctx, &testArtikel{ArtikelNr: "A1", Bezeichnung1: "Schraube", Gewicht: 0.5})
+	if err != nil {
+		t.Fatalf("Expected no error on Create, got %v", err)
+	}
+	if id != "A1" {
+		t.Errorf("Expected id 'A1', got '%s'", id)
+	}
+
+	art, err := res.Get(ctx, "A1")
+	if err != nil {
+		t.Fatalf("Expected no error on Get, got %v", err)
+	}
+	if art.Bezeichnung1 != "Schraube" || art.Gewicht != 0.5 {
+		t.Errorf("Unexpected article: %+v", art)
+	}
+
+	if err := res.Update(ctx, "A1", &testArtikel{Bezeichnung1: "Mutter"}); err != nil {
+		t.Fatalf("Expected no error on Update, got %v", err)
+	}
+	art, _ = res.Get(ctx, "A1")
+	if art.Bezeichnung1 != "Mutter" {
+		t.Errorf("Expected Bezeichnung1 'Mutter', got '%s'", art.Bezeichnung1)
+	}
+
+	if err := res.Delete(ctx, "A1"); err != nil {
+		t.Fatalf("Expected no error on Delete, got %v", err)
+	}
+
+	_, err = res.Get(ctx, "A1")
+	pxErr, ok := err.(*PxError)
+	if !ok || !pxErr.isNotFound() {
+		t.Errorf("Expected NOT_FOUND error, got %v", err)
+	}
+}
+
+func TestResource_ListAndAll(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	for _, nr := range []string{"A1", "A2", "A3", "A4", "A5"} {
+		px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": nr, "Bezeichnung1": "Artikel " + nr})
+	}
+	res := NewResource[testArtikel](px.client(&Options{Batchsize: 2}), "LAG/Artikel")
+
+	params := url.Values{}
+	params.Set("Limit", "2")
+	items, total, err := res.List(ctx, params)
+	if err != nil {
+		t.Fatalf("Expected no error on List, got %v", err)
+	}
+	if len(items) != 2 || total != 5 {
+		t.Errorf("Expected 2 items and total 5, got %v and %v", len(items), total)
+	}
+
+	all, err := res.All(ctx, nil)
+	if err != nil {
+		t.Fatalf("Expected no error on All, got %v", err)
+	}
+	if len(all) != 5 {
+		t.Errorf("Expected 5 items, got %v", len(all))
+	}
+	if all[4].ArtikelNr != "A5" {
+		t.Errorf("Expected last ArtikelNr 'A5', got '%s'", all[4].ArtikelNr)
+	}
+}
+
+func TestResource_AllEmpty(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	res := NewResource[testArtikel](px.client(nil), "LAG/Artikel")
+
+	all, err := res.All(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if all == nil || len(all) != 0 {
+		t.Errorf("Expected empty slice, got %v", all)
+	}
+}
+
+func TestResource_Save(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Bezeichnung1": "Schraube", "Gewicht": 0.5})
+	res := NewResource[testArtikel](px.client(nil), "LAG/Artikel")
+
+	original, err := res.Get(ctx, "A1")
+	if err != nil {
+		t.Fatalf("Expected no error on Get, got %v", err)
+	}
+
+	// Nothing changed -> no request
+	unchanged := *original
+	changed, err := res.Save(ctx, "A1", original, &unchanged)
+	if err != nil || changed {
+		t.Errorf("Expected no change, got %v (%v)", changed, err)
+	}
+	if px.callCount("PATCH", "LAG/Artikel") != 0 {
+		t.Errorf("Expected no PATCH request")
+	}
+
+	// Colleague changes Gewicht meanwhile; we only change Bezeichnung1
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Bezeichnung1": "Schraube", "Gewicht": 0.75})
+	modified := *original
+	modified.Bezeichnung1 = "Schraube M4"
+	changed, err = res.Save(ctx, "A1", original, &modified)
+	if err != nil || !changed {
+		t.Fatalf("Expected change, got %v (%v)", changed, err)
+	}
+
+	rec, _ := px.get("LAG/Artikel", "A1")
+	if rec["Bezeichnung1"] != "Schraube M4" || rec["Gewicht"] != 0.75 {
+		t.Errorf("Expected only Bezeichnung1 patched, got %v", rec)
+	}
+}
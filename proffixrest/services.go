@@ -0,0 +1,84 @@
+package proffixrest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/models"
+)
+
+// Adressen returns a typed service for endpoint ADR/Adresse.
+func (c *Client) Adressen() *Resource[models.Adresse] {
+	return NewResource[models.Adresse](c, "ADR/Adresse")
+}
+
+// Kontakte returns a typed service for endpoint ADR/Kontakt.
+func (c *Client) Kontakte() *Resource[models.Kontakt] {
+	return NewResource[models.Kontakt](c, "ADR/Kontakt")
+}
+
+// Artikel returns a typed service for endpoint LAG/Artikel.
+func (c *Client) Artikel() *Resource[models.Artikel] {
+	return NewResource[models.Artikel](c, "LAG/Artikel")
+}
+
+// Lager returns a typed service for endpoint LAG/Lager.
+func (c *Client) Lager() *Resource[models.Lager] {
+	return NewResource[models.Lager](c, "LAG/Lager")
+}
+
+// Dokumente returns a typed service for endpoint AUF/Dokument.
+func (c *Client) Dokumente() *Resource[models.Dokument] {
+	return NewResource[models.Dokument](c, "AUF/Dokument")
+}
+
+// Dokumentpositionen returns a typed service for the positions of the AUF document dokumentNr.
+func (c *Client) Dokumentpositionen(dokumentNr interface{}) *Resource[models.Dokumentposition] {
+	return NewResource[models.Dokumentposition](c, "AUF/Dokument/"+url.PathEscape(fmt.Sprintf("%v", dokumentNr))+"/Position")
+}
+
+// Buchungen returns a typed service for endpoint FIB/Buchung.
+func (c *Client) Buchungen() *Resource[models.Buchung] {
+	return NewResource[models.Buchung](c, "FIB/Buchung")
+}
+
+// Konten returns a typed service for endpoint FIB/Konto.
+func (c *Client) Konten() *Resource[models.Konto] {
+	return NewResource[models.Konto](c, "FIB/Konto")
+}
+
+// Rapporte returns a typed service for endpoint STU/Rapporte.
+func (c *Client) Rapporte() *Resource[models.Rapport] {
+	return NewResource[models.Rapport](c, "STU/Rapporte")
+}
+
+// InfoTyped retrieves PRO/Info decoded into models.Info.
+// If pxapi is empty the Key from Options is used.
+func (c *Client) InfoTyped(ctx context.Context, pxapi string) (*models.Info, error) {
+	rc, err := c.Info(ctx, pxapi)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.Info{}
+	if err := decodeBody(rc, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// DatabaseTyped retrieves PRO/Datenbank decoded into models.Datenbank.
+// If pxapi is empty the Key from Options is used.
+func (c *Client) DatabaseTyped(ctx context.Context, pxapi string) ([]models.Datenbank, error) {
+	rc, err := c.Database(ctx, pxapi)
+	if err != nil {
+		return nil, err
+	}
+
+	var databases []models.Datenbank
+	if err := decodeBody(rc, &databases); err != nil {
+		return nil, err
+	}
+	return databases, nil
+}
@@ -0,0 +1,83 @@
+package proffixrest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestClient_Services(t *testing.T) {
+	c, _ := NewClient("https://example.com", "USR", "pw", "DEMO", nil, nil)
+
+	endpoints := map[string]string{
+		c.Adressen().Endpoint():                "ADR/Adresse",
+		c.Kontakte().Endpoint():                "ADR/Kontakt",
+		c.Artikel().Endpoint():                 "LAG/Artikel",
+		c.Lager().Endpoint():                   "LAG/Lager",
+		c.Dokumente().Endpoint():               "AUF/Dokument",
+		c.Dokumentpositionen(42).Endpoint():    "AUF/Dokument/42/Position",
+		c.Dokumentpositionen("R/1").Endpoint(): "AUF/Dokument/R%2F1/Position",
+		c.Buchungen().Endpoint():               "FIB/Buchung",
+		c.Konten().Endpoint():                  "FIB/Konto",
+		c.Rapporte().Endpoint():                "STU/Rapporte",
+	}
+	for got, want := range endpoints {
+		if got != want {
+			t.Errorf("Expected endpoint '%s', got '%s'", want, got)
+		}
+	}
+}
+
+func TestClient_Adressen_Get(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 276, "Name": "EYX AG", "Vorname": nil, "Land": map[string]interface{}{"LandNr": "CH"}})
+
+	adresse, err := px.client(nil).Adressen().Get(context.Background(), 276)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if adresse.AdressNr != 276 || adresse.Name != "EYX AG" {
+		t.Errorf("Unexpected Adresse: %+v", adresse)
+	}
+	if adresse.Vorname != nil {
+		t.Errorf("Expected Vorname nil, got %v", *adresse.Vorname)
+	}
+	if adresse.Land == nil || adresse.Land.LandNr != "CH" {
+		t.Errorf("Expected LandNr 'CH', got %+v", adresse.Land)
+	}
+}
+
+func TestClient_InfoTyped(t *testing.T) {
+	px := newFakePX(t, map[string]string{})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		switch endpoint {
+		case "PRO/Info":
+			_, _ = w.Write([]byte(`{"Version":"4.0.1000","ServerZeit":"2024-03-01 08:15:30","Instanz":{"Name":"DEMO","Lizenzen":[{"Name":"ADR","Anzahl":5,"AnzahlInVerwendung":2}]}}`))
+		case "PRO/Datenbank":
+			_, _ = w.Write([]byte(`[{"Name":"DEMODB"}]`))
+		default:
+			return false
+		}
+		return true
+	}
+	c := px.client(&Options{Key: "key"})
+
+	info, err := c.InfoTyped(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if info.Version != "4.0.1000" || len(info.Instanz.Lizenzen) != 1 || info.Instanz.Lizenzen[0].AnzahlInVerwendung != 2 {
+		t.Errorf("Unexpected Info: %+v", info)
+	}
+	if info.ServerZeit.Hour() != 8 {
+		t.Errorf("Expected ServerZeit hour 8, got %v", info.ServerZeit.Hour())
+	}
+
+	databases, err := c.DatabaseTyped(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(databases) != 1 || databases[0].Name != "DEMODB" {
+		t.Errorf("Unexpected Datenbank: %+v", databases)
+	}
+}
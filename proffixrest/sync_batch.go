@@ -6,11 +6,129 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
+	"sync"
+	"time"
 )
 
 // SyncBatchData is a placeholder for batch requests
 type SyncBatchData map[string]interface{}
 
+// SyncBatchOptions configures SyncBatchWithOptions
+type SyncBatchOptions struct {
+	RemoveKeyfield bool          // Removes the keyfield on POST requests
+	Concurrency    int           // Number of items processed in parallel. Default is 1
+	ItemTimeout    time.Duration // Timeout for the requests of a single item. Default is no timeout
+
+	// Diff compares existing records with the item and sends only the changed fields as PATCH.
+	// Identical records are not sent and reported as unchanged.
+	Diff bool
+	// IgnoreFields are not compared in Diff mode and never sent for existing records (e.g. "GeaendertAm")
+	IgnoreFields []string
+
+	// Lookup matches existing records by these fields with a Filter query instead of the keyfield,
+	// e.g. []string{"Z_ExternalID"} or []string{"Name", "PLZ"}. Found records are updated with their primary key.
+	Lookup []string
+
+	// Journal records the progress of every item. Calling SyncBatchWithOptions again with the same input and
+	// journal skips the items already done (see SyncJournal and FileJournal).
+	Journal SyncJournal
+
+	// Mirror deletes or deactivates records missing in the input after the upsert pass (see MirrorOptions)
+	Mirror *MirrorOptions
+}
+
+// dryRun reports if the sync only plans the actions (MirrorOptions.DryRun)
+func (o *SyncBatchOptions) dryRun() bool {
+	return o.Mirror != nil && o.Mirror.DryRun
+}
+
+// SyncAction is the action taken for an item of a SyncBatch
+type SyncAction string
+
+// Actions of SyncItemResult
+const (
+	SyncCreated   SyncAction = "created"   // Item did not exist and was created (POST)
+	SyncUpdated   SyncAction = "updated"   // Item existed and was updated (PUT)
+	SyncUnchanged SyncAction = "unchanged" // Item existed and was identical (Diff mode)
+	SyncSkipped   SyncAction = "skipped"   // Item was not sent, e.g. because ctx was cancelled
+	SyncFailed    SyncAction = "failed"    // Request for the item failed
+)
+
+// SyncItemResult is the result of a single input item of a SyncBatch
+type SyncItemResult struct {
+	Index  int        `json:"Index"`           // Position of the item in the input (-1 for records removed in mirror mode)
+	Line   int        `json:"Line,omitempty"`  // Line of the item in the input if known
+	Key    string     `json:"Key"`             // Value of the keyfield in the input (empty if missing)
+	Action SyncAction `json:"Action"`          // Action taken for the item
+	Status int        `json:"Status"`          // HTTP status of the last request (0 if none was sent)
+	ID     string     `json:"ID,omitempty"`    // Key of the created entry taken from the Location header
+	Error  *PxError   `json:"Error,omitempty"` // Error of the item if it failed or was skipped
+
+	Resumed bool `json:"Resumed,omitempty"` // Item was already done according to the journal and not sent again
+}
+
+// SyncResult is the result of a SyncBatch with one entry per input item in input order.
+// Records removed in mirror mode follow the input items with Index -1.
+type SyncResult struct {
+	Total       int              `json:"Total"` // Number of input items
+	Created     int              `json:"Created"`
+	Updated     int              `json:"Updated"`
+	Unchanged   int              `json:"Unchanged"`
+	Skipped     int              `json:"Skipped"`
+	Failed      int              `json:"Failed"`
+	Deleted     int              `json:"Deleted"`
+	Deactivated int              `json:"Deactivated"`
+	Items       []SyncItemResult `json:"Items"`
+
+	DryRun            bool `json:"DryRun,omitempty"`            // Nothing was sent; the actions are the planned ones (MirrorOptions.DryRun)
+	ThresholdExceeded bool `json:"ThresholdExceeded,omitempty"` // The dry run would exceed MirrorOptions.MaxDeletePercent
+}
+
+// add appends an item and updates the counters
+func (r *SyncResult) add(item SyncItemResult) {
+	r.Items = append(r.Items, item)
+	if item.Index >= 0 {
+		r.Total++
+	}
+	switch item.Action {
+	case SyncCreated:
+		r.Created++
+	case SyncUpdated:
+		r.Updated++
+	case SyncUnchanged:
+		r.Unchanged++
+	case SyncSkipped:
+		r.Skipped++
+	case SyncDeleted:
+		r.Deleted++
+	case SyncDeactivated:
+		r.Deactivated++
+	default:
+		r.Failed++
+	}
+}
+
+// legacy converts the result into the slices returned by SyncBatch.
+// Unchanged items are reported as updated, skipped items as failed. Mirror items are left out.
+func (r *SyncResult) legacy() (created []string, updated []string, failed []string, errors []string, total int) {
+	for _, item := range r.Items {
+		if item.Index < 0 {
+			continue
+		}
+		switch item.Action {
+		case SyncCreated:
+			created = append(created, item.ID)
+		case SyncUpdated, SyncUnchanged:
+			updated = append(updated, item.Key)
+		default:
+			failed = append(failed, item.Key)
+			errors = append(errors, fmt.Sprintf("%v", item.Error))
+		}
+	}
+	return created, updated, failed, errors, r.Total
+}
+
 // SyncBatch automatically POST/PUT items based on Keyfield in Body
 // Params:
 //
@@ -29,82 +147,309 @@ type SyncBatchData map[string]interface{}
 //	total			int				Total requests
 //	err				error			General errors
 func (c *Client) SyncBatch(ctx context.Context, endpoint string, keyfield string, removeKeyfield bool, data []byte) (created []string, updated []string, failed []string, errors []string, total int, err error) {
-
-	var datas []SyncBatchData
-
-	err = json.Unmarshal(data, &datas)
+	res, err := c.SyncBatchWithOptions(ctx, endpoint, keyfield, data, &SyncBatchOptions{RemoveKeyfield: removeKeyfield})
 	if err != nil {
 		return nil, nil, nil, nil, 0, err
 	}
+	created, updated, failed, errors, total = res.legacy()
+	return created, updated, failed, errors, total, nil
+}
 
-	for i := range datas {
+// SyncBatchWithOptions works like SyncBatch but processes the items with a bounded number of workers
+// and returns a SyncResult with one entry per input item (see SyncStream).
+func (c *Client) SyncBatchWithOptions(ctx context.Context, endpoint string, keyfield string, data []byte, opts *SyncBatchOptions) (*SyncResult, error) {
+	// Reject invalid input before sending any request
+	trimmed := bytes.TrimSpace(data)
+	if !json.Valid(trimmed) || len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, &PxError{Message: "JSON Decoding failed: data must be a JSON array"}
+	}
+	return c.SyncStream(ctx, endpoint, keyfield, NewJSONSource(bytes.NewReader(trimmed)), opts)
+}
 
-		// Get Key from Map
-		key := fmt.Sprintf("%v", datas[i][keyfield])
+// SyncStream creates or updates the items of src like SyncBatchWithOptions while reading them one by one,
+// so feeds of any size are synced with constant memory (see NewJSONSource, NewCSVSource and NewChanSource).
+// Results are returned in the order of the input regardless of the completion order.
+// All requests go through the methods of the Client, so limits of an injected HTTPClient apply to every worker.
+// If ctx is cancelled, the remaining items are read and reported as skipped without sending them.
+// If src fails or the mirror mode aborts, the result so far is returned together with the error.
+func (c *Client) SyncStream(ctx context.Context, endpoint string, keyfield string, src SyncSource, opts *SyncBatchOptions) (*SyncResult, error) {
+	if opts == nil {
+		opts = &SyncBatchOptions{}
+	}
 
-		var (
-			statusGet int
-			getResp   io.Reader
-		)
+	dryRun := opts.dryRun()
 
-		// If Keyfield is empty / missing (saves a GET Request...)
-		if datas[i][keyfield] == "" {
-			statusGet = 404
-		} else {
-			getResp, _, statusGet, _ = c.Get(ctx, endpoint+"/"+key, nil)
+	var journal map[int]SyncJournalEntry
+	if opts.Journal != nil {
+		var err error
+		if journal, err = loadJournal(opts.Journal); err != nil {
+			return nil, err
 		}
+	}
+
+	// Login once up front so parallel workers share a single session
+	if err := c.Login(ctx); err != nil {
+		return nil, err
+	}
+
+	type syncJob struct {
+		index int
+		item  SyncBatchData
+	}
+
+	var (
+		mu         sync.Mutex
+		items      []SyncItemResult
+		journalErr error
+		srcErr     error
+	)
 
-		switch statusGet {
-		case 404:
-			// If Item not found -> create / post it with extracted keyfield
-			if removeKeyfield {
-				delete(datas[i], keyfield)
+	// setItem stores the result of an item; items grows while workers are running
+	setItem := func(i int, item SyncItemResult) {
+		mu.Lock()
+		item.Index, item.Line = i, items[i].Line
+		items[i] = item
+		mu.Unlock()
+	}
+
+	jobs := make(chan syncJob)
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			item, line, err := src.Next()
+			if err == io.EOF {
+				return
 			}
 
-			_, headers, status, err := c.Post(ctx, endpoint, datas[i])
-			if status == 201 {
-				// Append to created
-				created = append(created, ConvertLocationToID(headers))
-			} else {
-				// Append to failed
-				failed = append(failed, key)
-				errors = append(errors, fmt.Sprintf("%v", err))
+			mu.Lock()
+			items = append(items, SyncItemResult{Line: line})
+			mu.Unlock()
+
+			if err != nil {
+				if isInvalidInput(err) {
+					setItem(i, SyncItemResult{Action: SyncFailed, Error: toPxError(err, endpoint)})
+					continue
+				}
+				srcErr = err
+				return
 			}
 
-		case 200:
-			// If Item found -> update / put new values
-			res := ""
-			resp, _, status, err := c.Put(ctx, endpoint+"/"+key, datas[i])
-			if resp != nil {
-				// Buffer decode for plain text response
-				buf := new(bytes.Buffer)
-				_, _ = buf.ReadFrom(resp)
-				res = buf.String()
+			key := itemKey(item, keyfield)
+			entry, ok := journal[i]
+			if ok && entry.Key != key {
+				srcErr = &PxError{Type: "JOURNAL_MISMATCH", Message: fmt.Sprintf("Sync journal does not match the input at item %d", i)}
+				return
 			}
 
-			if status == 204 {
-				// Append to updated
-				updated = append(updated, key)
-			} else {
-				// Append to failed
-				failed = append(failed, key)
-				errors = append(errors, fmt.Sprintf("%v %v", err, res))
+			// Items done in a previous run are not sent again
+			if ok && entry.State == JournalDone {
+				setItem(i, resumedItem(entry))
+				continue
 			}
 
-		default:
-			// Unexpected status; append to failed
-			res := ""
-			if getResp != nil {
-				// Buffer decode for plain text response
-				buf := new(bytes.Buffer)
-				_, _ = buf.ReadFrom(getResp)
-				res = buf.String()
+			select {
+			case <-ctx.Done():
+				// Mark remaining items as skipped without sending them
+				setItem(i, SyncItemResult{Action: SyncSkipped, Key: key, Error: toPxError(ctx.Err(), endpoint)})
+			case jobs <- syncJob{index: i, item: item}:
+			}
+		}
+	}()
+
+	parallel(opts.Concurrency, jobs, func(job syncJob) {
+		itemCtx := ctx
+		if opts.ItemTimeout > 0 {
+			var cancel context.CancelFunc
+			itemCtx, cancel = context.WithTimeout(ctx, opts.ItemTimeout)
+			defer cancel()
+		}
+		inputKey := itemKey(job.item, keyfield)
+
+		// A POST without confirmation can only be retried if the record can be found again.
+		// Without the keyfield in the POST (RemoveKeyfield) PROFFIX assigned its own key, so only Lookup finds it.
+		if entry, ok := journal[job.index]; ok && entry.State == JournalPending && (inputKey == "" || opts.RemoveKeyfield) && len(opts.Lookup) == 0 {
+			setItem(job.index, SyncItemResult{Action: SyncFailed, Key: inputKey, Error: &PxError{Endpoint: endpoint, Type: "UNCONFIRMED_POST", Message: "POST was sent but not confirmed; check the record manually or set Lookup to reconcile the item"}})
+			return
+		}
+
+		result := c.syncItem(itemCtx, endpoint, keyfield, job.index, job.item, opts)
+		setItem(job.index, result)
+
+		if opts.Journal != nil && !dryRun {
+			if entry, ok := journalEntry(job.index, inputKey, result); ok {
+				if err := opts.Journal.Record(entry); err != nil {
+					mu.Lock()
+					journalErr = err
+					mu.Unlock()
+				}
+			}
+		}
+	})
+
+	res := &SyncResult{Items: make([]SyncItemResult, 0, len(items)), DryRun: dryRun}
+	for _, item := range items {
+		res.add(item)
+	}
+
+	if srcErr != nil {
+		return res, srcErr
+	}
+
+	if journalErr != nil {
+		return res, &PxError{Endpoint: endpoint, Message: fmt.Sprintf("Writing sync journal failed: %s", journalErr)}
+	}
+
+	if opts.Mirror != nil {
+		if err := c.mirror(ctx, endpoint, keyfield, res, opts); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// syncItem creates or updates a single item
+func (c *Client) syncItem(ctx context.Context, endpoint string, keyfield string, index int, item SyncBatchData, opts *SyncBatchOptions) SyncItemResult {
+
+	// Get Key from Map
+	key := itemKey(item, keyfield)
+
+	var (
+		statusGet int
+		errGet    error
+		existing  []byte
+	)
+
+	switch {
+	case len(opts.Lookup) > 0:
+		// Find the existing record by the lookup fields and resolve its key
+		key, statusGet, existing, errGet = c.lookupItem(ctx, endpoint, keyfield, item, opts)
+	case key == "":
+		// If Keyfield is empty / missing (saves a GET Request...)
+		statusGet = 404
+	default:
+		var getResp io.ReadCloser
+		getResp, _, statusGet, errGet = c.Get(WithoutCache(ctx), endpoint+"/"+url.PathEscape(key), nil)
+		if opts.Diff && statusGet == 200 {
+			existing, errGet = ReaderToByte(getResp)
+		}
+		closeBody(getResp)
+	}
+
+	// Keys may contain reserved characters such as "/", like in Resource.keyEndpoint
+	entryEndpoint := endpoint + "/" + url.PathEscape(key)
+
+	switch {
+	case statusGet == 404 && opts.dryRun():
+		return SyncItemResult{Action: SyncCreated, Key: key}
+
+	case statusGet == 404:
+		// If Item not found -> create / post it with extracted keyfield
+		body := item
+		if opts.RemoveKeyfield {
+			body = copyWithout(item, keyfield)
+		}
+
+		// Record the POST before sending it, so a resume can reconcile it
+		if opts.Journal != nil {
+			if err := opts.Journal.Record(SyncJournalEntry{Index: index, Key: itemKey(item, keyfield), State: JournalPending}); err != nil {
+				return SyncItemResult{Action: SyncFailed, Key: key, Error: &PxError{Endpoint: endpoint, Message: fmt.Sprintf("Writing sync journal failed: %s", err)}}
 			}
-			failed = append(failed, key)
-			errors = append(errors, fmt.Sprintf("%v %v", err, res))
 		}
 
+		resp, headers, status, err := c.Post(ctx, endpoint, body)
+		closeBody(resp)
+		if status == 201 {
+			return SyncItemResult{Action: SyncCreated, Key: key, Status: status, ID: ConvertLocationToID(headers)}
+		}
+		return SyncItemResult{Action: SyncFailed, Key: key, Status: status, Error: toPxError(err, endpoint)}
+
+	case statusGet == 200 && errGet == nil:
+		if opts.Diff {
+			return c.patchItem(ctx, entryEndpoint, key, existing, item, opts)
+		}
+		if opts.dryRun() {
+			return SyncItemResult{Action: SyncUpdated, Key: key}
+		}
+
+		// If Item found -> update / put new values without the ignored fields
+		resp, _, status, err := c.Put(ctx, entryEndpoint, copyWithout(item, opts.IgnoreFields...))
+		closeBody(resp)
+
+		if status == 204 {
+			return SyncItemResult{Action: SyncUpdated, Key: key, Status: status}
+		}
+		return SyncItemResult{Action: SyncFailed, Key: key, Status: status, Error: toPxError(err, entryEndpoint)}
+
+	default:
+		// Unexpected status; append to failed
+		return SyncItemResult{Action: SyncFailed, Key: key, Status: statusGet, Error: toPxError(errGet, entryEndpoint)}
+	}
+}
+
+// patchItem compares the existing record with item and sends the changed fields as PATCH
+func (c *Client) patchItem(ctx context.Context, endpoint string, key string, existing []byte, item SyncBatchData, opts *SyncBatchOptions) SyncItemResult {
+	var current map[string]interface{}
+	if err := unmarshalUseNumber(existing, &current); err != nil {
+		return SyncItemResult{Action: SyncFailed, Key: key, Status: 200, Error: &PxError{Endpoint: endpoint, Message: fmt.Sprintf("JSON Decoding failed: %s", err)}}
+	}
+
+	incoming := copyWithout(item, opts.IgnoreFields...)
+
+	// Only compare fields sent in the item; other fields of PROFFIX are left untouched
+	changes, err := ChangeSet(pruneTo(current, incoming), incoming)
+	if err != nil {
+		return SyncItemResult{Action: SyncFailed, Key: key, Status: 200, Error: toPxError(err, endpoint)}
+	}
+	if len(changes) == 0 {
+		return SyncItemResult{Action: SyncUnchanged, Key: key, Status: 200}
+	}
+	if opts.dryRun() {
+		return SyncItemResult{Action: SyncUpdated, Key: key}
 	}
 
-	return created, updated, failed, errors, len(datas), err
+	resp, _, status, err := c.Patch(ctx, endpoint, changes)
+	closeBody(resp)
+	if status == 204 || status == 200 {
+		return SyncItemResult{Action: SyncUpdated, Key: key, Status: status}
+	}
+	return SyncItemResult{Action: SyncFailed, Key: key, Status: status, Error: toPxError(err, endpoint)}
+}
+
+// pruneTo returns the fields of record which are present in shape; nested objects are pruned recursively
+func pruneTo(record map[string]interface{}, shape map[string]interface{}) map[string]interface{} {
+	pruned := make(map[string]interface{}, len(shape))
+	for k, v := range shape {
+		value, ok := record[k]
+		if !ok {
+			continue
+		}
+		nestedRecord, recordIsMap := value.(map[string]interface{})
+		nestedShape, shapeIsMap := v.(map[string]interface{})
+		if recordIsMap && shapeIsMap {
+			value = pruneTo(nestedRecord, nestedShape)
+		}
+		pruned[k] = value
+	}
+	return pruned
+}
+
+// itemKey returns the value of the keyfield as string or an empty string if it is missing
+func itemKey(item SyncBatchData, keyfield string) string {
+	value, ok := item[keyfield]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// copyWithout returns a shallow copy of item without the given fields
+func copyWithout(item SyncBatchData, fields ...string) SyncBatchData {
+	cp := make(SyncBatchData, len(item))
+	for k, v := range item {
+		cp[k] = v
+	}
+	for _, field := range fields {
+		delete(cp, field)
+	}
+	return cp
 }
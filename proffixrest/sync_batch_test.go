@@ -3,7 +3,11 @@ package proffixrest
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var artikel99999 = `{
@@ -128,3 +132,275 @@ func TestClient_SyncBatch(t *testing.T) {
 		t.Errorf("Expected HTTP Status Code 204. Got '%v'", err)
 	}
 }
+
+// syncTestItems builds count articles as JSON; every second one exists on the fake server
+func syncTestItems(px *fakePX, count int) []byte {
+	items := make([]map[string]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		nr := fmt.Sprintf("A%04d", i)
+		if i%2 == 0 {
+			px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": nr, "Bezeichnung1": "alt"})
+		}
+		items = append(items, map[string]interface{}{"ArtikelNr": nr, "Bezeichnung1": "neu " + nr})
+	}
+	data, _ := json.Marshal(items)
+	return data
+}
+
+func TestClient_SyncBatchWithOptions_Concurrent(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+
+	// Track parallel requests on the server
+	var inFlight, maxInFlight int32
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return false
+	}
+
+	data := syncTestItems(px, 200)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{Concurrency: 8})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Total != 200 || res.Created != 100 || res.Updated != 100 || res.Failed != 0 {
+		t.Errorf("Expected 200 total, 100 created, 100 updated. Got %+v", res)
+	}
+	if atomic.LoadInt32(&maxInFlight) < 2 {
+		t.Errorf("Expected parallel requests, max in flight was %v", maxInFlight)
+	}
+
+	// Results keep the input order
+	for i, item := range res.Items {
+		want := SyncUpdated
+		if i%2 == 1 {
+			want = SyncCreated
+		}
+		if item.Index != i || item.Key != fmt.Sprintf("A%04d", i) || item.Action != want {
+			t.Fatalf("Unexpected item %d: %+v", i, item)
+		}
+	}
+
+	// Every article was written
+	for i := 0; i < 200; i++ {
+		nr := fmt.Sprintf("A%04d", i)
+		if rec, ok := px.get("LAG/Artikel", nr); !ok || rec["Bezeichnung1"] != "neu "+nr {
+			t.Fatalf("Expected %s to be synced, got %v", nr, rec)
+		}
+	}
+}
+
+func TestClient_SyncBatchWithOptions_ItemTimeout(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint == "LAG/Artikel/SLOW" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return false
+	}
+
+	data := []byte(`[{"ArtikelNr":"SLOW"},{"ArtikelNr":"FAST"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{Concurrency: 2, ItemTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Items[0].Action != SyncFailed || res.Items[0].Error == nil {
+		t.Errorf("Expected SLOW to fail, got %+v", res.Items[0])
+	}
+	if res.Items[1].Action != SyncCreated || res.Items[1].ID != "FAST" {
+		t.Errorf("Expected FAST to be created, got %+v", res.Items[1])
+	}
+}
+
+func TestClient_SyncBatch_Offline(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 276, "Name": "EYX AG"})
+
+	data := []byte(`[{"AdressNr": 276, "Name": "EYX AG", "Vorname": null}, {"Name": "Muster", "Vorname": "Hans"}]`)
+	created, updated, failed, _, total, err := px.client(nil).SyncBatch(context.Background(), "ADR/Adresse", "AdressNr", true, data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if total != 2 || len(updated) != 1 || updated[0] != "276" || len(created) != 1 || len(failed) != 0 {
+		t.Errorf("Unexpected result: created %v, updated %v, failed %v", created, updated, failed)
+	}
+	if px.callCount("GET", "ADR/Adresse") != 1 {
+		t.Errorf("Expected a single GET for the item with key")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_Result(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 276, "Name": "EYX AG"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodPost && endpoint == "ADR/Adresse" {
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["Name"] == "" {
+				w.WriteHeader(422)
+				writeJSON(w, PxError{Type: "INVALID_FIELDS", Message: "Ein oder mehrere Felder sind ungültig", Fields: []PxInvalidField{{Name: "Name", Reason: "MISSING", Message: "Name fehlt"}}})
+				return true
+			}
+			// Body was consumed; serve the create manually
+			px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 1001, "Name": body["Name"]})
+			w.Header().Set("Location", "http://localhost/pxapi/v4/ADR/Adresse/1001")
+			w.WriteHeader(http.StatusCreated)
+			return true
+		}
+		return false
+	}
+
+	data := []byte(`[{"AdressNr": 276, "Name": "EYX AG"}, {"Name": "Muster"}, {"Name": ""}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", data, &SyncBatchOptions{RemoveKeyfield: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Total != 3 || res.Updated != 1 || res.Created != 1 || res.Failed != 1 {
+		t.Errorf("Unexpected counters: %+v", res)
+	}
+
+	if item := res.Items[0]; item.Key != "276" || item.Action != SyncUpdated || item.Status != 204 {
+		t.Errorf("Unexpected updated item: %+v", item)
+	}
+	if item := res.Items[1]; item.Key != "" || item.Action != SyncCreated || item.Status != 201 || item.ID != "1001" {
+		t.Errorf("Unexpected created item: %+v", item)
+	}
+
+	item := res.Items[2]
+	if item.Index != 2 || item.Action != SyncFailed || item.Status != 422 {
+		t.Fatalf("Unexpected failed item: %+v", item)
+	}
+	if item.Error == nil || item.Error.Type != "INVALID_FIELDS" || len(item.Error.Fields) != 1 || item.Error.Fields[0].Name != "Name" {
+		t.Errorf("Expected typed INVALID_FIELDS error, got %+v", item.Error)
+	}
+
+	// Result is JSON serialisable
+	out, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("Expected no error on Marshal, got %v", err)
+	}
+	var back SyncResult
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatalf("Expected no error on Unmarshal, got %v", err)
+	}
+	if back.Failed != 1 || back.Items[2].Error.Type != "INVALID_FIELDS" || back.Items[1].Error != nil {
+		t.Errorf("Unexpected round trip: %s", out)
+	}
+}
+
+func TestClient_SyncBatchWithOptions_Cancelled(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	c := px.client(nil)
+
+	// Login first, then cancel so no item is started
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatalf("Expected no error on Login, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := c.SyncBatchWithOptions(ctx, "LAG/Artikel", "ArtikelNr", []byte(`[{"ArtikelNr":"A1"},{"ArtikelNr":"A2"}]`), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Skipped+res.Failed != 2 || res.Created != 0 {
+		t.Errorf("Expected no item to be synced, got %+v", res)
+	}
+	if px.count("LAG/Artikel") != 0 {
+		t.Errorf("Expected no article to be created")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_Diff(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Bezeichnung1": "Schraube", "Preis": 145, "GeaendertAm": "2024-01-01 10:00:00", "Lager": map[string]interface{}{"LagerNr": 1, "Bezeichnung": "Haupt"}})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A2", "Bezeichnung1": "Mutter", "Bezeichnung2": "M4", "Preis": 2.5})
+
+	// A1 only differs in ignored and unknown-to-item fields, A2 changes Bezeichnung1
+	data := []byte(`[
+		{"ArtikelNr": "A1", "Bezeichnung1": "Schraube", "Preis": 145.00, "GeaendertAm": "2025-06-01 08:00:00", "Lager": {"LagerNr": 1}},
+		{"ArtikelNr": "A2", "Bezeichnung1": "Mutter M4", "Preis": 2.50},
+		{"ArtikelNr": "A3", "Bezeichnung1": "Neu"}
+	]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{Diff: true, IgnoreFields: []string{"GeaendertAm"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Unchanged != 1 || res.Updated != 1 || res.Created != 1 || res.Failed != 0 {
+		t.Errorf("Unexpected counters: %+v", res)
+	}
+	if res.Items[0].Action != SyncUnchanged || res.Items[1].Action != SyncUpdated {
+		t.Errorf("Unexpected actions: %v, %v", res.Items[0].Action, res.Items[1].Action)
+	}
+
+	if px.callCount("PUT", "LAG/Artikel") != 0 {
+		t.Errorf("Expected no PUT in diff mode")
+	}
+	if px.callCount("PATCH", "LAG/Artikel") != 1 {
+		t.Errorf("Expected a single PATCH, got %v", px.callCount("PATCH", "LAG/Artikel"))
+	}
+
+	// Fields not in the item are kept
+	rec, _ := px.get("LAG/Artikel", "A2")
+	if rec["Bezeichnung1"] != "Mutter M4" || rec["Bezeichnung2"] != "M4" {
+		t.Errorf("Expected only Bezeichnung1 patched, got %v", rec)
+	}
+	rec, _ = px.get("LAG/Artikel", "A1")
+	if rec["GeaendertAm"] != "2024-01-01 10:00:00" {
+		t.Errorf("Expected ignored field untouched, got %v", rec["GeaendertAm"])
+	}
+
+	// Legacy slices report unchanged items as updated
+	_, updated, _, _, _ := res.legacy()
+	if len(updated) != 2 {
+		t.Errorf("Expected 2 updated in legacy result, got %v", updated)
+	}
+}
+
+func TestClient_SyncBatchWithOptions_PutIgnoreFieldsAndEscapedKey(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A/1", "Bezeichnung1": "Schraube", "GeaendertAm": "2024-01-01 10:00:00"})
+
+	data := []byte(`[{"ArtikelNr": "A/1", "Bezeichnung1": "Schraube M4", "GeaendertAm": "2025-06-01 08:00:00"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{IgnoreFields: []string{"GeaendertAm"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Updated != 1 || res.Failed != 0 {
+		t.Errorf("Expected 1 updated, got %+v", res)
+	}
+
+	// The key is escaped, so "A/1" is not split into two segments
+	rec, ok := px.get("LAG/Artikel", "A/1")
+	if !ok || rec["Bezeichnung1"] != "Schraube M4" {
+		t.Errorf("Expected A/1 to be updated, got %v", rec)
+	}
+	if rec["GeaendertAm"] == "2025-06-01 08:00:00" {
+		t.Errorf("Expected ignored field not to be sent with PUT, got %v", rec["GeaendertAm"])
+	}
+}
+
+func TestPruneTo(t *testing.T) {
+	record := map[string]interface{}{"A": 1, "B": 2, "N": map[string]interface{}{"X": 1, "Y": 2}}
+	shape := map[string]interface{}{"A": 0, "N": map[string]interface{}{"X": 0}, "Z": 0}
+
+	got := pruneTo(record, shape)
+	if len(got) != 2 || got["A"] != 1 {
+		t.Errorf("Unexpected pruned record: %v", got)
+	}
+	if n := got["N"].(map[string]interface{}); len(n) != 1 || n["X"] != 1 {
+		t.Errorf("Expected nested record pruned, got %v", n)
+	}
+}
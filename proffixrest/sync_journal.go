@@ -0,0 +1,136 @@
+package proffixrest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// States of a SyncJournalEntry
+const (
+	JournalPending = "pending" // POST was sent but not confirmed yet
+	JournalDone    = "done"    // Item was synced successfully
+	JournalFailed  = "failed"  // Item failed and is retried on resume
+)
+
+// SyncJournalEntry is the checkpoint of a single input item of a SyncBatch
+type SyncJournalEntry struct {
+	Index       int        `json:"Index"`
+	Key         string     `json:"Key"`                   // Value of the keyfield in the input
+	State       string     `json:"State"`                 // JournalPending, JournalDone or JournalFailed
+	Action      SyncAction `json:"Action,omitempty"`      // Action of a done item
+	ID          string     `json:"ID,omitempty"`          // Key of the created entry
+	ResolvedKey string     `json:"ResolvedKey,omitempty"` // Key resolved by Lookup if it differs from Key
+}
+
+// SyncJournal records the progress of a SyncBatch so an interrupted batch can be resumed.
+// Record must be safe for concurrent use.
+type SyncJournal interface {
+	// Load returns the latest entry of every item recorded so far
+	Load() (map[int]SyncJournalEntry, error)
+	// Record stores an entry; later entries of the same item replace earlier ones
+	Record(entry SyncJournalEntry) error
+}
+
+// FileJournal is a SyncJournal writing one JSON line per entry to a file
+type FileJournal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileJournal opens or creates a journal file. Existing entries are kept for resuming.
+func NewFileJournal(path string) (*FileJournal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) // #nosec G304 -- caller controls journal path by design
+	if err != nil {
+		return nil, err
+	}
+	return &FileJournal{path: path, file: file}, nil
+}
+
+// Load reads all entries of the journal file. A truncated last line (e.g. after a crash) is ignored.
+func (j *FileJournal) Load() (map[int]SyncJournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	file, err := os.Open(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	entries := map[int]SyncJournalEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry SyncJournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries[entry.Index] = entry
+	}
+	return entries, scanner.Err()
+}
+
+// Record appends an entry to the journal file and syncs it to disk
+func (j *FileJournal) Record(entry SyncJournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Close closes the journal file
+func (j *FileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// loadJournal loads the entries of journal
+func loadJournal(journal SyncJournal) (map[int]SyncJournalEntry, error) {
+	entries, err := journal.Load()
+	if err != nil {
+		return nil, &PxError{Message: fmt.Sprintf("Loading sync journal failed: %s", err)}
+	}
+	return entries, nil
+}
+
+// journalEntry converts the result of an item into its journal entry.
+// Returns false if the outcome is unknown and a pending entry must be kept.
+func journalEntry(index int, inputKey string, item SyncItemResult) (SyncJournalEntry, bool) {
+	switch item.Action {
+	case SyncCreated, SyncUpdated, SyncUnchanged:
+		entry := SyncJournalEntry{Index: index, Key: inputKey, State: JournalDone, Action: item.Action, ID: item.ID}
+		if item.Key != inputKey {
+			entry.ResolvedKey = item.Key
+		}
+		return entry, true
+	case SyncFailed:
+		// Without a response a POST may have been applied
+		if item.Status == 0 {
+			return SyncJournalEntry{}, false
+		}
+		return SyncJournalEntry{Index: index, Key: inputKey, State: JournalFailed}, true
+	default:
+		return SyncJournalEntry{}, false
+	}
+}
+
+// resumedItem converts a done journal entry back into its result
+func resumedItem(entry SyncJournalEntry) SyncItemResult {
+	key := entry.Key
+	if entry.ResolvedKey != "" {
+		key = entry.ResolvedKey
+	}
+	return SyncItemResult{Key: key, Action: entry.Action, ID: entry.ID, Resumed: true}
+}
@@ -0,0 +1,160 @@
+package proffixrest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClient_SyncBatchWithOptions_JournalResume(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	journal, err := NewFileJournal(filepath.Join(t.TempDir(), "sync.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = journal.Close() }()
+
+	data := []byte(`[{"Z_ID": "X1", "Name": "Eins"}, {"Z_ID": "X2", "Name": "Zwei"}, {"Z_ID": "X3", "Name": "Drei"}, {"Z_ID": "X4", "Name": "Vier"}]`)
+	opts := &SyncBatchOptions{Lookup: []string{"Z_ID"}, Journal: journal}
+
+	// The POST of X3 is applied but the connection drops before the response
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodPost && endpoint == "ADR/Adresse" {
+			rec := decodeRecord(r.Body)
+			rec["AdressNr"] = rec["Z_ID"]
+			px.put("ADR/Adresse", rec)
+			if rec["Z_ID"] == "X3" {
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Set("Location", "http://localhost/pxapi/v4/ADR/Adresse/"+rec["Z_ID"].(string))
+			w.WriteHeader(http.StatusCreated)
+			return true
+		}
+		return false
+	}
+
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", data, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Created != 3 || res.Failed != 1 || res.Items[2].Status != 0 {
+		t.Fatalf("Expected X3 to fail without response, got %+v", res)
+	}
+
+	entries, _ := journal.Load()
+	if entries[2].State != JournalPending || entries[0].State != JournalDone || entries[0].ID != "X1" {
+		t.Errorf("Unexpected journal entries: %+v", entries)
+	}
+
+	// Resume: done items are skipped, the pending item is reconciled by lookup instead of a second POST
+	px.handler = nil
+	posts := px.callCount("POST", "ADR/Adresse")
+	res, err = px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", data, opts)
+	if err != nil {
+		t.Fatalf("Expected no error on resume, got %v", err)
+	}
+
+	if px.callCount("POST", "ADR/Adresse") != posts {
+		t.Errorf("Expected no POST on resume")
+	}
+	if px.count("ADR/Adresse") != 4 {
+		t.Errorf("Expected 4 addresses without duplicates, got %v", px.count("ADR/Adresse"))
+	}
+	if !res.Items[0].Resumed || res.Items[0].ID != "X1" || res.Items[0].Action != SyncCreated {
+		t.Errorf("Expected X1 resumed from the journal, got %+v", res.Items[0])
+	}
+	if item := res.Items[2]; item.Resumed || item.Action != SyncUpdated {
+		t.Errorf("Expected X3 to be reconciled and updated, got %+v", item)
+	}
+	if res.Created != 3 || res.Updated != 1 || res.Failed != 0 {
+		t.Errorf("Unexpected counters on resume: %+v", res)
+	}
+}
+
+func TestClient_SyncBatchWithOptions_JournalUnconfirmedPost(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	journal, err := NewFileJournal(filepath.Join(t.TempDir(), "sync.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = journal.Close() }()
+	_ = journal.Record(SyncJournalEntry{Index: 0, State: JournalPending})
+
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", []byte(`[{"Name": "Muster"}]`), &SyncBatchOptions{Journal: journal})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if item := res.Items[0]; item.Action != SyncFailed || item.Error == nil || item.Error.Type != "UNCONFIRMED_POST" {
+		t.Errorf("Expected UNCONFIRMED_POST, got %+v", item)
+	}
+	if px.callCount("POST", "ADR/Adresse") != 0 {
+		t.Errorf("Expected no POST for an unconfirmed item")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_JournalUnconfirmedPostRemoveKeyfield(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	// The first run created the record with a key assigned by PROFFIX, but the response was lost
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": "1001", "Name": "Muster"})
+	journal, err := NewFileJournal(filepath.Join(t.TempDir(), "sync.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = journal.Close() }()
+	_ = journal.Record(SyncJournalEntry{Index: 0, Key: "X1", State: JournalPending})
+
+	opts := &SyncBatchOptions{Journal: journal, RemoveKeyfield: true}
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", []byte(`[{"AdressNr": "X1", "Name": "Muster"}]`), opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if item := res.Items[0]; item.Action != SyncFailed || item.Error == nil || item.Error.Type != "UNCONFIRMED_POST" || item.Key != "X1" {
+		t.Errorf("Expected UNCONFIRMED_POST for manual review, got %+v", item)
+	}
+	if px.callCount("POST", "ADR/Adresse") != 0 || px.count("ADR/Adresse") != 1 {
+		t.Errorf("Expected no second POST, got %d records", px.count("ADR/Adresse"))
+	}
+
+	// The entry stays pending until it is reconciled
+	entries, _ := journal.Load()
+	if entries[0].State != JournalPending {
+		t.Errorf("Expected journal entry to stay pending, got %+v", entries[0])
+	}
+}
+
+func TestFileJournal_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.jsonl")
+	content := `{"Index":0,"Key":"A1","State":"pending"}
+{"Index":0,"Key":"A1","State":"done","Action":"created","ID":"A1"}
+{"Index":1,"Key":"A2","State":"failed"}
+{"Index":2,"Key":"A3","Sta`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	journal, err := NewFileJournal(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = journal.Close() }()
+
+	entries, err := journal.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 2 || entries[0].State != JournalDone || entries[1].State != JournalFailed {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	// Journal of another input is rejected
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	_, err = px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", []byte(`[{"ArtikelNr": "B1"}, {"ArtikelNr": "A2"}]`), &SyncBatchOptions{Journal: journal})
+	if pxErr, ok := err.(*PxError); !ok || pxErr.Type != "JOURNAL_MISMATCH" {
+		t.Errorf("Expected JOURNAL_MISMATCH, got %v", err)
+	}
+	if px.count("LAG/Artikel") != 0 {
+		t.Errorf("Expected no article to be synced")
+	}
+}
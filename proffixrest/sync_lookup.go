@@ -0,0 +1,72 @@
+package proffixrest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// lookupItem finds the existing record of item by the lookup fields.
+// Returns status 404 if there is none and status 200 with the resolved key if there is exactly one.
+// Multiple matches fail with an error of Type "MULTIPLE_MATCHES".
+func (c *Client) lookupItem(ctx context.Context, endpoint string, keyfield string, item SyncBatchData, opts *SyncBatchOptions) (key string, status int, existing []byte, err error) {
+	filter, err := lookupFilter(item, opts.Lookup)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	params := url.Values{}
+	params.Set("Filter", filter)
+	params.Set("Limit", "2")
+	if !opts.Diff {
+		params.Set("Fields", keyfield)
+	}
+
+	rc, _, status, err := c.Get(WithoutCache(ctx), endpoint, params)
+	if err != nil {
+		closeBody(rc)
+		return "", status, nil, err
+	}
+
+	body, err := ReaderToByte(rc)
+	closeBody(rc)
+	if err != nil {
+		return "", status, nil, err
+	}
+
+	var matches []SyncBatchData
+	if err := unmarshalUseNumber(body, &matches); err != nil {
+		return "", status, nil, &PxError{Endpoint: endpoint, Status: status, Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", 404, nil, nil
+	case 1:
+		key = itemKey(matches[0], keyfield)
+		if key == "" {
+			return "", status, nil, &PxError{Endpoint: endpoint, Status: status, Message: fmt.Sprintf("Lookup result has no keyfield %s", keyfield)}
+		}
+		if opts.Diff {
+			existing, err = json.Marshal(matches[0])
+		}
+		return key, status, existing, err
+	default:
+		return "", status, nil, &PxError{Endpoint: endpoint, Status: status, Type: "MULTIPLE_MATCHES", Message: fmt.Sprintf("Multiple records match %s", filter)}
+	}
+}
+
+// lookupFilter builds a PROFFIX filter matching all fields of item, e.g. "Name=='Muster',PLZ=='8000'"
+func lookupFilter(item SyncBatchData, fields []string) (string, error) {
+	clauses := make([]string, 0, len(fields))
+	for _, field := range fields {
+		value, ok := item[field]
+		if !ok || value == nil {
+			return "", &PxError{Type: "INVALID_LOOKUP", Message: fmt.Sprintf("Lookup field %s is missing", field)}
+		}
+		clauses = append(clauses, fmt.Sprintf("%s=='%s'", field, strings.ReplaceAll(fmt.Sprintf("%v", value), "'", "''")))
+	}
+	return strings.Join(clauses, ","), nil
+}
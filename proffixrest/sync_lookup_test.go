@@ -0,0 +1,85 @@
+package proffixrest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClient_SyncBatchWithOptions_Lookup(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 276, "Z_ExternalID": "CRM-1", "Name": "EYX AG", "PLZ": "8000"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 277, "Z_ExternalID": "CRM-2", "Name": "O'Neill", "PLZ": "8000"})
+
+	data := []byte(`[
+		{"Z_ExternalID": "CRM-1", "Name": "EYX AG neu", "PLZ": "8000"},
+		{"Z_ExternalID": "CRM-3", "Name": "Muster", "PLZ": "3000"}
+	]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", data, &SyncBatchOptions{Lookup: []string{"Z_ExternalID"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if item := res.Items[0]; item.Action != SyncUpdated || item.Key != "276" {
+		t.Errorf("Expected CRM-1 to update AdressNr 276, got %+v", item)
+	}
+	if item := res.Items[1]; item.Action != SyncCreated || item.ID != "1001" {
+		t.Errorf("Expected CRM-3 to be created, got %+v", item)
+	}
+
+	rec, _ := px.get("ADR/Adresse", "276")
+	if rec["Name"] != "EYX AG neu" {
+		t.Errorf("Expected Name updated, got %v", rec["Name"])
+	}
+	if px.callCount("PUT", "ADR/Adresse/276") != 1 {
+		t.Errorf("Expected PUT to the resolved key")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_LookupComposite(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 276, "Name": "O'Neill", "PLZ": "8000", "Ort": "Zürich"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 277, "Name": "O'Neill", "PLZ": "3000", "Ort": "Bern"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 278, "Name": "Muster", "PLZ": "3000", "Ort": "Bern"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 279, "Name": "Muster", "PLZ": "3000", "Ort": "Bern"})
+
+	data := []byte(`[
+		{"Name": "O'Neill", "PLZ": "3000", "Ort": "Bern-Bümpliz"},
+		{"Name": "Muster", "PLZ": "3000", "Ort": "Bern"},
+		{"Name": "Ohne PLZ"}
+	]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "ADR/Adresse", "AdressNr", data, &SyncBatchOptions{Lookup: []string{"Name", "PLZ"}, Diff: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if item := res.Items[0]; item.Action != SyncUpdated || item.Key != "277" {
+		t.Errorf("Expected O'Neill 3000 to update 277, got %+v", item)
+	}
+	rec, _ := px.get("ADR/Adresse", "277")
+	if rec["Ort"] != "Bern-Bümpliz" {
+		t.Errorf("Expected Ort patched, got %v", rec["Ort"])
+	}
+
+	item := res.Items[1]
+	if item.Action != SyncFailed || item.Error == nil || item.Error.Type != "MULTIPLE_MATCHES" {
+		t.Errorf("Expected MULTIPLE_MATCHES, got %+v", item)
+	}
+
+	item = res.Items[2]
+	if item.Action != SyncFailed || item.Error == nil || item.Error.Type != "INVALID_LOOKUP" {
+		t.Errorf("Expected INVALID_LOOKUP, got %+v", item)
+	}
+	if px.count("ADR/Adresse") != 4 {
+		t.Errorf("Expected no record to be created")
+	}
+}
+
+func TestLookupFilter(t *testing.T) {
+	filter, err := lookupFilter(SyncBatchData{"Name": "O'Neill", "PLZ": "8000"}, []string{"Name", "PLZ"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if filter != "Name=='O''Neill',PLZ=='8000'" {
+		t.Errorf("Unexpected filter: %s", filter)
+	}
+}
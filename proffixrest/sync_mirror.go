@@ -0,0 +1,117 @@
+package proffixrest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// MirrorOptions configures the mirror mode of SyncBatchWithOptions.
+// After the upsert pass all records of the endpoint (scoped by Filter) whose keys are missing in the input
+// are deleted or deactivated, so PROFFIX matches the input exactly.
+type MirrorOptions struct {
+	Filter           string                 // PROFFIX filter scoping the mirrored records, e.g. "Gruppe=='SHOP'"
+	Deactivate       map[string]interface{} // PATCH body to deactivate missing records, e.g. {"Geloescht": 1}. If nil they are deleted
+	DryRun           bool                   // Only report the upserts and removals; no write is sent and the journal is not updated
+	MaxDeletePercent float64                // Aborts if more than this percentage of the scoped records would be removed. 0 means no limit
+}
+
+// Actions of mirror mode; the items are appended after the input items with Index -1
+const (
+	SyncDeleted     SyncAction = "deleted"     // Record was missing in the input and deleted
+	SyncDeactivated SyncAction = "deactivated" // Record was missing in the input and deactivated (PATCH)
+)
+
+// mirror removes all records of endpoint which are not part of the synced input
+func (c *Client) mirror(ctx context.Context, endpoint string, keyfield string, res *SyncResult, opts *SyncBatchOptions) error {
+	mopts := opts.Mirror
+
+	// Removing records after a partial sync would delete records that only failed to sync
+	if res.Failed > 0 || res.Skipped > 0 {
+		return &PxError{Endpoint: endpoint, Type: "MIRROR_ABORTED", Message: fmt.Sprintf("Mirror aborted: %d items failed and %d were skipped", res.Failed, res.Skipped)}
+	}
+
+	synced := make(map[string]bool, len(res.Items))
+	for _, item := range res.Items {
+		if item.Key != "" {
+			synced[item.Key] = true
+		}
+		if item.ID != "" {
+			synced[item.ID] = true
+		}
+	}
+
+	params := url.Values{}
+	if mopts.Filter != "" {
+		params.Set("Filter", mopts.Filter)
+	}
+	// Without all remote keys, missing records would be kept and the threshold computed on a wrong total
+	remote, err := c.enumerateKeys(ctx, endpoint, keyfield, params)
+	if err != nil {
+		pxErr := toPxError(err, endpoint)
+		return &PxError{Endpoint: endpoint, Status: pxErr.Status, Type: "MIRROR_ABORTED", Message: fmt.Sprintf("Mirror aborted: reading the records failed: %s", pxErr.Message), cause: err}
+	}
+
+	var missing []string
+	for _, key := range remote {
+		if !synced[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if mopts.MaxDeletePercent > 0 {
+		percent := float64(len(missing)) * 100 / float64(len(remote))
+		if percent > mopts.MaxDeletePercent && mopts.DryRun {
+			// A preview lists the records anyway and reports the breach
+			res.ThresholdExceeded = true
+		} else if percent > mopts.MaxDeletePercent {
+			return &PxError{Endpoint: endpoint, Type: "MIRROR_THRESHOLD", Message: fmt.Sprintf("Mirror aborted: %d of %d records (%.1f%%) would be removed, limit is %.1f%%", len(missing), len(remote), percent, mopts.MaxDeletePercent)}
+		}
+	}
+
+	action := SyncDeleted
+	if mopts.Deactivate != nil {
+		action = SyncDeactivated
+	}
+
+	items := make([]SyncItemResult, len(missing))
+	if mopts.DryRun {
+		for i, key := range missing {
+			items[i] = SyncItemResult{Index: -1, Key: key, Action: action}
+		}
+	} else {
+		parallelN(opts.Concurrency, len(missing), func(i int) {
+			items[i] = c.removeRecord(ctx, endpoint+"/"+url.PathEscape(missing[i]), missing[i], action, mopts.Deactivate)
+		})
+	}
+
+	for _, item := range items {
+		res.add(item)
+	}
+	return nil
+}
+
+// removeRecord deletes or deactivates a single record
+func (c *Client) removeRecord(ctx context.Context, endpoint string, key string, action SyncAction, deactivate map[string]interface{}) SyncItemResult {
+	var (
+		status int
+		err    error
+	)
+	if action == SyncDeactivated {
+		rc, _, s, e := c.Patch(ctx, endpoint, deactivate)
+		closeBody(rc)
+		status, err = s, e
+	} else {
+		rc, _, s, e := c.Delete(ctx, endpoint)
+		closeBody(rc)
+		status, err = s, e
+	}
+
+	if err != nil {
+		return SyncItemResult{Index: -1, Key: key, Action: SyncFailed, Status: status, Error: toPxError(err, endpoint)}
+	}
+	return SyncItemResult{Index: -1, Key: key, Action: action, Status: status}
+}
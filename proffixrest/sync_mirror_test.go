@@ -0,0 +1,192 @@
+package proffixrest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// mirrorFake returns a fake with articles A1..A5 of group SHOP and B1 of group B2B
+func mirrorFake(t *testing.T) *fakePX {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	for _, nr := range []string{"A1", "A2", "A3", "A4", "A5"} {
+		px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": nr, "Gruppe": "SHOP", "Geloescht": 0})
+	}
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "B1", "Gruppe": "B2B", "Geloescht": 0})
+	return px
+}
+
+func TestClient_SyncBatchWithOptions_MirrorDelete(t *testing.T) {
+	px := mirrorFake(t)
+
+	data := []byte(`[{"ArtikelNr": "A1", "Gruppe": "SHOP"}, {"ArtikelNr": "A2", "Gruppe": "SHOP"}, {"ArtikelNr": "A6", "Gruppe": "SHOP"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Mirror: &MirrorOptions{Filter: "Gruppe=='SHOP'"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Total != 3 || res.Created != 1 || res.Updated != 2 || res.Deleted != 3 {
+		t.Errorf("Unexpected counters: %+v", res)
+	}
+	if len(res.Items) != 6 || res.Items[3].Index != -1 || res.Items[3].Key != "A3" || res.Items[3].Action != SyncDeleted {
+		t.Errorf("Unexpected mirror items: %+v", res.Items)
+	}
+
+	// Records outside the filter are kept
+	if _, ok := px.get("LAG/Artikel", "B1"); !ok {
+		t.Errorf("Expected B1 outside of the filter to be kept")
+	}
+	for _, nr := range []string{"A3", "A4", "A5"} {
+		if _, ok := px.get("LAG/Artikel", nr); ok {
+			t.Errorf("Expected %s to be deleted", nr)
+		}
+	}
+	if px.count("LAG/Artikel") != 4 {
+		t.Errorf("Expected 4 articles left, got %v", px.count("LAG/Artikel"))
+	}
+}
+
+func TestClient_SyncBatchWithOptions_MirrorDeactivate(t *testing.T) {
+	px := mirrorFake(t)
+
+	data := []byte(`[{"ArtikelNr": "A1"}, {"ArtikelNr": "A2"}, {"ArtikelNr": "A3"}, {"ArtikelNr": "A4"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Concurrency: 4,
+		Mirror:      &MirrorOptions{Filter: "Gruppe=='SHOP'", Deactivate: map[string]interface{}{"Geloescht": 1}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Deactivated != 1 || res.Deleted != 0 {
+		t.Errorf("Expected 1 deactivated, got %+v", res)
+	}
+	rec, ok := px.get("LAG/Artikel", "A5")
+	if !ok || rec["Geloescht"] != float64(1) {
+		t.Errorf("Expected A5 to be deactivated, got %v", rec)
+	}
+	if px.callCount("DELETE", "LAG/Artikel") != 0 {
+		t.Errorf("Expected no DELETE when deactivating")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_MirrorDryRun(t *testing.T) {
+	px := mirrorFake(t)
+
+	data := []byte(`[{"ArtikelNr": "A1"}, {"ArtikelNr": "A6"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Mirror: &MirrorOptions{Filter: "Gruppe=='SHOP'", DryRun: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !res.DryRun || res.Created != 1 || res.Updated != 1 || res.Deleted != 4 {
+		t.Errorf("Expected 1 create, 1 update and 4 deletions to be planned, got %+v", res)
+	}
+	for _, item := range res.Items {
+		if item.Status != 0 {
+			t.Errorf("Expected no request in dry run, got %+v", item)
+		}
+	}
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		if n := px.callCount(method, "LAG/Artikel"); n != 0 {
+			t.Errorf("Expected no %s in dry run, got %d", method, n)
+		}
+	}
+	if px.count("LAG/Artikel") != 6 {
+		t.Errorf("Expected nothing to be changed in dry run")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_MirrorDryRunThreshold(t *testing.T) {
+	px := mirrorFake(t)
+
+	// 4 of 5 SHOP articles (80%) would be removed; the preview lists them instead of aborting
+	data := []byte(`[{"ArtikelNr": "A1"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Diff:   true,
+		Mirror: &MirrorOptions{Filter: "Gruppe=='SHOP'", DryRun: true, MaxDeletePercent: 50},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !res.ThresholdExceeded || res.Deleted != 4 {
+		t.Errorf("Expected threshold breach with 4 planned deletions, got %+v", res)
+	}
+	if px.callCount("PATCH", "LAG/Artikel") != 0 || px.callCount("DELETE", "LAG/Artikel") != 0 {
+		t.Errorf("Expected no writes in dry run")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_MirrorThreshold(t *testing.T) {
+	px := mirrorFake(t)
+
+	// 4 of 5 SHOP articles (80%) would be removed
+	data := []byte(`[{"ArtikelNr": "A1"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Mirror: &MirrorOptions{Filter: "Gruppe=='SHOP'", MaxDeletePercent: 50},
+	})
+
+	pxErr, ok := err.(*PxError)
+	if !ok || pxErr.Type != "MIRROR_THRESHOLD" {
+		t.Fatalf("Expected MIRROR_THRESHOLD error, got %v", err)
+	}
+	if res == nil || res.Updated != 1 || res.Deleted != 0 {
+		t.Errorf("Expected upsert result without deletions, got %+v", res)
+	}
+	if px.count("LAG/Artikel") != 6 {
+		t.Errorf("Expected nothing to be deleted")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_MirrorAbortsOnFailure(t *testing.T) {
+	px := mirrorFake(t)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodPut && endpoint == "LAG/Artikel/A2" {
+			writePxError(w, 500, "SERVER_ERROR", "Interner Fehler")
+			return true
+		}
+		return false
+	}
+
+	data := []byte(`[{"ArtikelNr": "A1"}, {"ArtikelNr": "A2"}]`)
+	res, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Mirror: &MirrorOptions{Filter: "Gruppe=='SHOP'"},
+	})
+
+	pxErr, ok := err.(*PxError)
+	if !ok || pxErr.Type != "MIRROR_ABORTED" {
+		t.Fatalf("Expected MIRROR_ABORTED error, got %v", err)
+	}
+	if res.Failed != 1 || px.count("LAG/Artikel") != 6 {
+		t.Errorf("Expected no deletions after a failed item, got %+v", res)
+	}
+}
+
+func TestClient_SyncBatchWithOptions_MirrorAbortsOnIncompleteRead(t *testing.T) {
+	px := mirrorFake(t)
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodGet && endpoint == "LAG/Artikel" && strings.Contains(r.URL.Query().Get("Filter"), "ArtikelNr>") {
+			writePxError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "Server busy")
+			return true
+		}
+		return false
+	}
+
+	data := []byte(`[{"ArtikelNr": "A1", "Gruppe": "SHOP"}]`)
+	_, err := px.client(&Options{Batchsize: 2}).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", data, &SyncBatchOptions{
+		Mirror: &MirrorOptions{Filter: "Gruppe=='SHOP'"},
+	})
+	var pxErr *PxError
+	if !errors.As(err, &pxErr) || pxErr.Type != "MIRROR_ABORTED" || pxErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected MIRROR_ABORTED with status 503, got %v", err)
+	}
+	if n := px.callCount("DELETE", "LAG/Artikel"); n != 0 || px.count("LAG/Artikel") != 6 {
+		t.Errorf("Expected nothing to be deleted, got %d DELETE requests", n)
+	}
+}
@@ -0,0 +1,351 @@
+package proffixrest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/decimal"
+)
+
+// SyncSource provides the items of a SyncStream one by one.
+//
+// Next returns the next item and its line in the input (0 if unknown) or io.EOF at the end.
+// Errors of Type "INVALID_INPUT" only concern the current item, which is reported as failed, and the stream continues.
+// All other errors abort the stream.
+type SyncSource interface {
+	Next() (item SyncBatchData, line int, err error)
+}
+
+// invalidInput returns a recoverable source error for a single item
+func invalidInput(line int, format string, args ...interface{}) *PxError {
+	return &PxError{Type: "INVALID_INPUT", Message: fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, args...))}
+}
+
+// isInvalidInput checks if err is a recoverable source error
+func isInvalidInput(err error) bool {
+	var pxErr *PxError
+	return errors.As(err, &pxErr) && pxErr.Type == "INVALID_INPUT"
+}
+
+// jsonSource reads a JSON array or NDJSON
+type jsonSource struct {
+	reader *bufio.Reader
+	lines  *lineReader
+	dec    *json.Decoder
+	line   int
+	array  bool
+	init   bool
+}
+
+// NewJSONSource returns a SyncSource reading a JSON array of objects or NDJSON (one object per line) from r.
+// The format is detected from the first character. Numbers are kept as json.Number.
+func NewJSONSource(r io.Reader) SyncSource {
+	return &jsonSource{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next object of the JSON array or NDJSON
+func (s *jsonSource) Next() (SyncBatchData, int, error) {
+	if !s.init {
+		s.init = true
+		if err := s.start(); err != nil {
+			return nil, 0, err
+		}
+	}
+	if s.array {
+		return s.nextArray()
+	}
+	return s.nextLine()
+}
+
+// start detects the format and consumes the opening bracket of an array
+func (s *jsonSource) start() error {
+	for {
+		b, err := s.reader.Peek(1)
+		if err != nil {
+			return err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = s.reader.ReadByte()
+			if b[0] == '\n' {
+				s.line++
+			}
+			continue
+		case '[':
+			s.array = true
+			s.lines = &lineReader{r: s.reader, base: s.line}
+			s.dec = json.NewDecoder(s.lines)
+			s.dec.UseNumber()
+			_, err := s.dec.Token()
+			return err
+		}
+		return nil
+	}
+}
+
+// nextArray decodes the next element of a JSON array
+func (s *jsonSource) nextArray() (SyncBatchData, int, error) {
+	if !s.dec.More() {
+		if _, err := s.dec.Token(); err != nil {
+			return nil, 0, &PxError{Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+		}
+		return nil, 0, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		return nil, 0, &PxError{Message: fmt.Sprintf("JSON Decoding failed: %s", err)}
+	}
+	start := s.dec.InputOffset() - int64(len(raw))
+	line := s.lines.lineAt(start)
+	s.lines.forget(start)
+
+	var item SyncBatchData
+	if err := unmarshalUseNumber(raw, &item); err != nil || item == nil {
+		return nil, line, invalidInput(line, "item is not a JSON object")
+	}
+	return item, line, nil
+}
+
+// nextLine decodes the next non-empty line of NDJSON
+func (s *jsonSource) nextLine() (SyncBatchData, int, error) {
+	for {
+		data, err := s.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return nil, 0, err
+		}
+		s.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			if err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		var item SyncBatchData
+		if uerr := unmarshalUseNumber(data, &item); uerr != nil || item == nil {
+			return nil, s.line, invalidInput(s.line, "invalid JSON object")
+		}
+		return item, s.line, nil
+	}
+}
+
+// lineReader counts the lines of the bytes read through it.
+// Offsets of newlines are kept until forget is called, so memory stays bounded.
+type lineReader struct {
+	r        io.Reader
+	offset   int64
+	newlines []int64
+	base     int // lines before the first kept newline
+}
+
+// Read reads from the underlying reader and records newline offsets
+func (l *lineReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == '\n' {
+			l.newlines = append(l.newlines, l.offset+int64(i))
+		}
+	}
+	l.offset += int64(n)
+	return n, err
+}
+
+// lineAt returns the 1-based line of the byte at offset
+func (l *lineReader) lineAt(offset int64) int {
+	n := sort.Search(len(l.newlines), func(i int) bool { return l.newlines[i] >= offset })
+	return l.base + n + 1
+}
+
+// forget drops the newlines before offset
+func (l *lineReader) forget(offset int64) {
+	n := sort.Search(len(l.newlines), func(i int) bool { return l.newlines[i] >= offset })
+	l.base += n
+	l.newlines = append(l.newlines[:0], l.newlines[n:]...)
+}
+
+// CSVType defines how a CSV value is converted
+type CSVType int
+
+// Types of CSV values
+const (
+	CSVString  CSVType = iota // Value as is
+	CSVInt                    // Integer, e.g. "1'234" or "1234"
+	CSVDecimal                // Exact decimal number, e.g. "12.50" or "12,50"
+	CSVBool                   // "1", "true", "ja", "yes" or "x" are true; "0", "false", "nein", "no" and "" are false
+)
+
+// CSVSourceOptions configures NewCSVSource
+type CSVSourceOptions struct {
+	Comma       rune               // Field delimiter. Default is ';'
+	Mapping     map[string]string  // Maps CSV columns to fields; "Land.LandNr" creates nested objects. If nil all columns are used as is
+	Types       map[string]CSVType // Type of a field (after mapping). Default is CSVString
+	EmptyAsNull bool               // Empty values are sent as null instead of ""
+}
+
+// csvSource reads items from CSV
+type csvSource struct {
+	reader *csv.Reader
+	opts   CSVSourceOptions
+	header []string
+}
+
+// NewCSVSource returns a SyncSource reading CSV with a header row from r.
+// Columns are mapped to fields and converted according to opts.
+func NewCSVSource(r io.Reader, opts *CSVSourceOptions) SyncSource {
+	s := &csvSource{}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Comma == 0 {
+		s.opts.Comma = ';'
+	}
+
+	s.reader = csv.NewReader(r)
+	s.reader.Comma = s.opts.Comma
+	s.reader.FieldsPerRecord = -1
+	return s
+}
+
+// Next returns the next row as item
+func (s *csvSource) Next() (SyncBatchData, int, error) {
+	if s.header == nil {
+		header, err := s.reader.Read()
+		if err != nil {
+			return nil, 0, err
+		}
+		// Remove UTF-8 BOM of Excel exports
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		s.header = header
+	}
+
+	record, err := s.reader.Read()
+	if err == io.EOF {
+		return nil, 0, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, invalidInput(parseErr.StartLine, "%s", parseErr.Err)
+		}
+		return nil, 0, err
+	}
+	line, _ := s.reader.FieldPos(0)
+
+	item := SyncBatchData{}
+	for i, column := range s.header {
+		field := column
+		if s.opts.Mapping != nil {
+			var ok bool
+			if field, ok = s.opts.Mapping[column]; !ok {
+				continue
+			}
+		}
+
+		var raw string
+		if i < len(record) {
+			raw = record[i]
+		}
+
+		value, err := s.convert(field, raw)
+		if err != nil {
+			return nil, line, invalidInput(line, "column %s: %s", column, err)
+		}
+		setField(item, field, value)
+	}
+	return item, line, nil
+}
+
+// convert converts a CSV value to the type of field
+func (s *csvSource) convert(field string, raw string) (interface{}, error) {
+	value := strings.TrimSpace(raw)
+	typ := s.opts.Types[field]
+
+	if value == "" {
+		switch {
+		case typ == CSVBool:
+			return false, nil
+		case typ == CSVInt || typ == CSVDecimal || s.opts.EmptyAsNull:
+			return nil, nil
+		}
+	}
+
+	switch typ {
+	case CSVInt, CSVDecimal:
+		// Swiss thousands separator and decimal comma
+		value = strings.NewReplacer("'", "", "’", "", ",", ".").Replace(value)
+		d, err := decimal.NewFromString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", raw)
+		}
+		if typ == CSVInt && d.Scale() > 0 {
+			if d.Round(0).Cmp(d) != 0 {
+				return nil, fmt.Errorf("invalid integer %q", raw)
+			}
+			d = d.Round(0)
+		}
+		return json.Number(d.String()), nil
+	case CSVBool:
+		switch strings.ToLower(value) {
+		case "1", "true", "ja", "yes", "x":
+			return true, nil
+		case "", "0", "false", "nein", "no":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", raw)
+	default:
+		return raw, nil
+	}
+}
+
+// setField sets a value in item; dots in field create nested objects
+func setField(item SyncBatchData, field string, value interface{}) {
+	parts := strings.Split(field, ".")
+	current := map[string]interface{}(item)
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// chanSource reads items from a channel
+type chanSource struct {
+	ctx   context.Context
+	ch    <-chan SyncBatchData
+	count int
+}
+
+// NewChanSource returns a SyncSource reading items from ch until it is closed or ctx is done.
+// The line of an item is its position in the channel.
+func NewChanSource(ctx context.Context, ch <-chan SyncBatchData) SyncSource {
+	return &chanSource{ctx: ctx, ch: ch}
+}
+
+// Next returns the next item of the channel
+func (s *chanSource) Next() (SyncBatchData, int, error) {
+	select {
+	case <-s.ctx.Done():
+		return nil, 0, s.ctx.Err()
+	case item, ok := <-s.ch:
+		if !ok {
+			return nil, 0, io.EOF
+		}
+		s.count++
+		return item, s.count, nil
+	}
+}
@@ -0,0 +1,199 @@
+package proffixrest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll reads all items of src and returns them with their lines and errors
+func readAll(t *testing.T, src SyncSource) (items []SyncBatchData, lines []int, errs []error) {
+	t.Helper()
+	for {
+		item, line, err := src.Next()
+		if err == io.EOF {
+			return items, lines, errs
+		}
+		if err != nil && !isInvalidInput(err) {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		items = append(items, item)
+		lines = append(lines, line)
+		errs = append(errs, err)
+	}
+}
+
+func TestJSONSource_Array(t *testing.T) {
+	input := `
+[
+  {"ArtikelNr": "A1", "Preis": 12.50},
+  {
+    "ArtikelNr": "A2"
+  }, 42,
+  {"ArtikelNr": "A3"}
+]`
+	items, lines, errs := readAll(t, NewJSONSource(strings.NewReader(input)))
+
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 items, got %v", len(items))
+	}
+	if want := []int{3, 4, 6, 7}; lines[0] != want[0] || lines[1] != want[1] || lines[2] != want[2] || lines[3] != want[3] {
+		t.Errorf("Expected lines %v, got %v", want, lines)
+	}
+	if items[0]["Preis"] != json.Number("12.50") {
+		t.Errorf("Expected Preis as json.Number 12.50, got %#v", items[0]["Preis"])
+	}
+	if errs[2] == nil || !strings.Contains(errs[2].Error(), "line 6") {
+		t.Errorf("Expected INVALID_INPUT for a non-object, got %v", errs[2])
+	}
+}
+
+func TestJSONSource_ArrayTruncated(t *testing.T) {
+	src := NewJSONSource(strings.NewReader(`[{"ArtikelNr": "A1"}, {"ArtikelNr": `))
+	if _, _, err := src.Next(); err != nil {
+		t.Fatalf("Expected first item, got %v", err)
+	}
+	if _, _, err := src.Next(); err == nil || err == io.EOF || isInvalidInput(err) {
+		t.Errorf("Expected fatal error for a truncated array, got %v", err)
+	}
+}
+
+func TestJSONSource_NDJSON(t *testing.T) {
+	input := "{\"ArtikelNr\": \"A1\"}\n\n{\"ArtikelNr\": \n{\"ArtikelNr\": \"A3\"}"
+	items, lines, errs := readAll(t, NewJSONSource(strings.NewReader(input)))
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %v", len(items))
+	}
+	if lines[0] != 1 || lines[1] != 3 || lines[2] != 4 {
+		t.Errorf("Expected lines 1, 3, 4, got %v", lines)
+	}
+	if errs[1] == nil || errs[0] != nil || errs[2] != nil {
+		t.Errorf("Expected only line 3 to fail, got %v", errs)
+	}
+	if items[2]["ArtikelNr"] != "A3" {
+		t.Errorf("Expected last line without newline, got %v", items[2])
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	input := "\ufeffNr;Name;Land;Preis;Menge;Aktiv;Intern\n" +
+		"A1;Schraube;CH;1'234,50;10;ja;x\n" +
+		"A2;Mutter;;;;nein;y\n" +
+		"A3;Falsch;DE;abc;1;ja;z\n"
+
+	src := NewCSVSource(strings.NewReader(input), &CSVSourceOptions{
+		Mapping: map[string]string{"Nr": "ArtikelNr", "Name": "Bezeichnung1", "Land": "Land.LandNr", "Preis": "Verkaufspreis1", "Menge": "Menge", "Aktiv": "Aktiv"},
+		Types:   map[string]CSVType{"Verkaufspreis1": CSVDecimal, "Menge": CSVInt, "Aktiv": CSVBool},
+	})
+	items, lines, errs := readAll(t, src)
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %v", len(items))
+	}
+	if lines[0] != 2 || lines[2] != 4 {
+		t.Errorf("Expected lines 2..4, got %v", lines)
+	}
+
+	first := items[0]
+	if first["ArtikelNr"] != "A1" || first["Verkaufspreis1"] != json.Number("1234.50") || first["Menge"] != json.Number("10") || first["Aktiv"] != true {
+		t.Errorf("Unexpected first item: %v", first)
+	}
+	if land, ok := first["Land"].(map[string]interface{}); !ok || land["LandNr"] != "CH" {
+		t.Errorf("Expected nested Land, got %v", first["Land"])
+	}
+	if _, ok := first["Intern"]; ok {
+		t.Errorf("Expected unmapped column to be ignored")
+	}
+
+	second := items[1]
+	if second["Verkaufspreis1"] != nil || second["Menge"] != nil || second["Aktiv"] != false {
+		t.Errorf("Expected empty numbers as null, got %v", second)
+	}
+
+	if errs[2] == nil || !strings.Contains(errs[2].Error(), "line 4") || !strings.Contains(errs[2].Error(), "Preis") {
+		t.Errorf("Expected INVALID_INPUT for line 4, got %v", errs[2])
+	}
+}
+
+func TestCSVSource_NoMapping(t *testing.T) {
+	src := NewCSVSource(strings.NewReader("ArtikelNr,Bezeichnung1\nA1,\n"), &CSVSourceOptions{Comma: ',', EmptyAsNull: true})
+	items, _, _ := readAll(t, src)
+
+	if len(items) != 1 || items[0]["ArtikelNr"] != "A1" || items[0]["Bezeichnung1"] != nil {
+		t.Errorf("Unexpected items: %v", items)
+	}
+}
+
+func TestChanSource(t *testing.T) {
+	ch := make(chan SyncBatchData, 2)
+	ch <- SyncBatchData{"ArtikelNr": "A1"}
+	ch <- SyncBatchData{"ArtikelNr": "A2"}
+	close(ch)
+
+	items, lines, _ := readAll(t, NewChanSource(context.Background(), ch))
+	if len(items) != 2 || lines[1] != 2 {
+		t.Errorf("Unexpected items: %v, lines %v", items, lines)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := NewChanSource(ctx, make(chan SyncBatchData)).Next(); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestClient_SyncStream(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Bezeichnung1": "alt"})
+
+	input := "ArtikelNr;Bezeichnung1;Preis\nA1;Schraube;1.50\nA2;Mutter;x\nA3;Scheibe;0.10\n"
+	src := NewCSVSource(strings.NewReader(input), &CSVSourceOptions{Types: map[string]CSVType{"Preis": CSVDecimal}})
+
+	res, err := px.client(nil).SyncStream(context.Background(), "LAG/Artikel", "ArtikelNr", src, &SyncBatchOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if res.Total != 3 || res.Updated != 1 || res.Created != 1 || res.Failed != 1 {
+		t.Errorf("Unexpected counters: %+v", res)
+	}
+	if item := res.Items[1]; item.Line != 3 || item.Error == nil || item.Error.Type != "INVALID_INPUT" {
+		t.Errorf("Expected invalid line 3, got %+v", item)
+	}
+	if item := res.Items[2]; item.Line != 4 || item.Index != 2 || item.Action != SyncCreated {
+		t.Errorf("Expected A3 created from line 4, got %+v", item)
+	}
+}
+
+func TestClient_SyncStream_SourceError(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	src := NewJSONSource(strings.NewReader(`[{"ArtikelNr": "A1"}, {"ArtikelNr": `))
+
+	res, err := px.client(nil).SyncStream(context.Background(), "LAG/Artikel", "ArtikelNr", src, &SyncBatchOptions{
+		Mirror: &MirrorOptions{},
+	})
+	if err == nil {
+		t.Fatalf("Expected error for a truncated input")
+	}
+	if res == nil || res.Created != 1 {
+		t.Errorf("Expected the result so far, got %+v", res)
+	}
+	if px.callCount("DELETE", "LAG/Artikel") != 0 {
+		t.Errorf("Expected no mirror after a source error")
+	}
+}
+
+func TestClient_SyncBatchWithOptions_InvalidJSON(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	for _, data := range []string{`[{"ArtikelNr": "A1"`, `{"ArtikelNr": "A1"}`, ``} {
+		if _, err := px.client(nil).SyncBatchWithOptions(context.Background(), "LAG/Artikel", "ArtikelNr", []byte(data), nil); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+	if px.callCount("POST", "LAG/Artikel") != 0 {
+		t.Errorf("Expected no request for invalid input")
+	}
+}
@@ -4,12 +4,15 @@ import (
 	"net/http"
 	"path"
 	"time"
+
+	"github.com/pitwch/go-wrapper-proffix-restapi/proffixrest/pxtime"
 )
 
 // PXTime is the layout used to parse PROFFIX REST-API timestamps.
 const PXTime = "2006-01-02 15:04:05"
 
 // ConvertPXTimeToTime converts a PROFFIX REST-API timestamp string to time.Time.
+// The timestamp is read as UTC and errors result in a zero time; use ParsePXTime instead.
 func ConvertPXTimeToTime(pxtime string) (t time.Time) {
 	t, _ = time.Parse(PXTime, pxtime) // Ignore error, return zero time on failure
 	return t
@@ -20,6 +23,26 @@ func ConvertTimeToPXTime(t time.Time) (pxtime string) {
 	return t.Format(PXTime)
 }
 
+// ParsePXTime parses a PROFFIX REST-API timestamp in the location of package pxtime (Europe/Zurich by default).
+func ParsePXTime(s string) (time.Time, error) {
+	return pxtime.Parse(s)
+}
+
+// ParsePXDate parses a PROFFIX REST-API date (with or without time 00:00:00) in the location of package pxtime.
+func ParsePXDate(s string) (time.Time, error) {
+	return pxtime.ParseDate(s)
+}
+
+// FormatPXTime converts t to the location of package pxtime and formats it as PROFFIX REST-API timestamp.
+func FormatPXTime(t time.Time) string {
+	return pxtime.Format(t)
+}
+
+// FormatPXDate converts t to the location of package pxtime and formats it as PROFFIX REST-API date.
+func FormatPXDate(t time.Time) string {
+	return pxtime.FormatDate(t)
+}
+
 // ConvertLocationToID extracts the trailing resource identifier from a Location header.
 func ConvertLocationToID(header http.Header) (id string) {
 	return path.Base(header.Get("Location"))
@@ -44,3 +44,35 @@ func TestTimeConversion(t *testing.T) {
 	}
 
 }
+
+func TestParsePXTime(t *testing.T) {
+	ts, err := ParsePXTime("2024-07-01 12:30:00")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !ts.Equal(time.Date(2024, 7, 1, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected 10:30 UTC, got %v", ts.UTC())
+	}
+	if FormatPXTime(ts.UTC()) != "2024-07-01 12:30:00" {
+		t.Errorf("Expected '2024-07-01 12:30:00', got '%s'", FormatPXTime(ts.UTC()))
+	}
+
+	// Unlike ConvertPXTimeToTime errors are returned
+	if _, err := ParsePXTime("01.07.2024"); err == nil {
+		t.Errorf("Expected error for invalid timestamp")
+	}
+}
+
+func TestParsePXDate(t *testing.T) {
+	d, err := ParsePXDate("1980-05-17")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if FormatPXDate(d) != "1980-05-17" {
+		t.Errorf("Expected '1980-05-17', got '%s'", FormatPXDate(d))
+	}
+
+	if _, err := ParsePXDate(""); err == nil {
+		t.Errorf("Expected error for empty date")
+	}
+}
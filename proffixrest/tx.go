@@ -0,0 +1,239 @@
+package proffixrest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Tx records successful writes together with a compensating action, so a multi-step write
+// (e.g. address, document and positions) can be undone if a later step fails.
+// PROFFIX has no transactions; the compensations are regular requests run in reverse order by Rollback.
+//
+//   - Post is compensated by deleting the created entry (key from the Location header).
+//   - Put is compensated by restoring the snapshot fetched before the update.
+//   - Patch is compensated by restoring the previous values of the patched fields.
+type Tx struct {
+	client *Client
+	mu     sync.Mutex
+	steps  []txStep
+}
+
+// txStep is a successful write with its compensation
+type txStep struct {
+	method     string
+	endpoint   string
+	compensate func(ctx context.Context) error
+}
+
+// CompensationError is a compensation which failed during Rollback
+type CompensationError struct {
+	Method   string // Method of the compensated write
+	Endpoint string // Endpoint of the compensated write
+	Err      error
+}
+
+// Error formats the failed compensation
+func (e CompensationError) Error() string {
+	return fmt.Sprintf("compensating %s %s failed: %v", e.Method, e.Endpoint, e.Err)
+}
+
+// RollbackError is returned if compensations failed during Rollback.
+// The entries listed in Failed must be cleaned up manually.
+type RollbackError struct {
+	Cause  error // Error which caused the rollback (nil if Rollback was called directly)
+	Failed []CompensationError
+}
+
+// Error formats the rollback error
+func (e *RollbackError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		msgs[i] = f.Error()
+	}
+	msg := fmt.Sprintf("rollback incomplete: %s", strings.Join(msgs, "; "))
+	if e.Cause != nil {
+		msg = fmt.Sprintf("%v (%s)", e.Cause, msg)
+	}
+	return msg
+}
+
+// Unwrap returns the error which caused the rollback
+func (e *RollbackError) Unwrap() error {
+	return e.Cause
+}
+
+// BeginTx starts a new Tx on the client.
+func (c *Client) BeginTx() *Tx {
+	return &Tx{client: c}
+}
+
+// RunTx runs fn in a new Tx. If fn returns an error or panics, all writes of the Tx are compensated.
+// Returns the error of fn, or a *RollbackError wrapping it if compensations failed.
+// If ctx is already done, the rollback runs with a background context so the compensations are still sent.
+func (c *Client) RunTx(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	tx := c.BeginTx()
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.rollback(rollbackContext(ctx))
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.rollback(rollbackContext(ctx)); rbErr != nil {
+			rbErr.Cause = err
+			return rbErr
+		}
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// rollbackContext returns ctx or a background context if ctx is already done
+func rollbackContext(ctx context.Context) context.Context {
+	if ctx.Err() != nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// Post sends a POST request and registers the deletion of the created entry as compensation.
+func (tx *Tx) Post(ctx context.Context, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	rc, header, status, err := tx.client.Post(ctx, endpoint, data)
+	if err != nil {
+		return rc, header, status, err
+	}
+
+	id := ConvertLocationToID(header)
+	tx.add(http.MethodPost, endpoint, func(ctx context.Context) error {
+		if id == "" {
+			return &PxError{Endpoint: endpoint, Message: "created entry has no Location header"}
+		}
+		return tx.client.deleteEntry(ctx, endpoint+"/"+id)
+	})
+	return rc, header, status, nil
+}
+
+// Put fetches a snapshot of the entry, sends a PUT request and registers restoring the snapshot as compensation.
+func (tx *Tx) Put(ctx context.Context, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	snapshot, err := tx.client.snapshot(ctx, endpoint)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	rc, header, status, err := tx.client.Put(ctx, endpoint, data)
+	if err != nil {
+		return rc, header, status, err
+	}
+
+	tx.add(http.MethodPut, endpoint, func(ctx context.Context) error {
+		rc, _, _, err := tx.client.Put(ctx, endpoint, snapshot)
+		closeBody(rc)
+		return err
+	})
+	return rc, header, status, nil
+}
+
+// Patch fetches a snapshot of the entry, sends a PATCH request and registers restoring the patched fields as compensation.
+func (tx *Tx) Patch(ctx context.Context, endpoint string, data interface{}) (io.ReadCloser, http.Header, int, error) {
+	changes, err := toJSONMap(data)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	snapshot, err := tx.client.snapshot(ctx, endpoint)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	rc, header, status, err := tx.client.Patch(ctx, endpoint, data)
+	if err != nil {
+		return rc, header, status, err
+	}
+
+	// Fields missing in the snapshot did not exist before and are reset to null
+	previous := pruneTo(snapshot, changes)
+	for field := range changes {
+		if _, ok := previous[field]; !ok {
+			previous[field] = nil
+		}
+	}
+
+	tx.add(http.MethodPatch, endpoint, func(ctx context.Context) error {
+		rc, _, _, err := tx.client.Patch(ctx, endpoint, previous)
+		closeBody(rc)
+		return err
+	})
+	return rc, header, status, nil
+}
+
+// Commit ends the Tx; the recorded writes are kept and Rollback does nothing anymore.
+func (tx *Tx) Commit() {
+	tx.mu.Lock()
+	tx.steps = nil
+	tx.mu.Unlock()
+}
+
+// Rollback runs the compensations of all recorded writes in reverse order.
+// All compensations are attempted; if any fails a *RollbackError listing them is returned.
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if rbErr := tx.rollback(ctx); rbErr != nil {
+		return rbErr
+	}
+	return nil
+}
+
+// rollback runs the compensations and returns the failed ones
+func (tx *Tx) rollback(ctx context.Context) *RollbackError {
+	tx.mu.Lock()
+	steps := tx.steps
+	tx.steps = nil
+	tx.mu.Unlock()
+
+	var failed []CompensationError
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if err := step.compensate(ctx); err != nil {
+			logDebug(ctx, tx.client, fmt.Sprintf("Rollback of %s %s failed: %v", step.method, step.endpoint, err))
+			failed = append(failed, CompensationError{Method: step.method, Endpoint: step.endpoint, Err: err})
+		}
+	}
+
+	if len(failed) > 0 {
+		return &RollbackError{Failed: failed}
+	}
+	return nil
+}
+
+// add records a successful write
+func (tx *Tx) add(method string, endpoint string, compensate func(ctx context.Context) error) {
+	tx.mu.Lock()
+	tx.steps = append(tx.steps, txStep{method: method, endpoint: endpoint, compensate: compensate})
+	tx.mu.Unlock()
+}
+
+// snapshot fetches an entry with numbers as json.Number
+func (c *Client) snapshot(ctx context.Context, endpoint string) (map[string]interface{}, error) {
+	rc, _, _, err := c.Get(WithoutCache(ctx), endpoint, nil)
+	if err != nil {
+		closeBody(rc)
+		return nil, err
+	}
+	defer closeBody(rc)
+	return GetMapUseNumber(rc)
+}
+
+// deleteEntry deletes an entry; an entry which is already gone counts as deleted
+func (c *Client) deleteEntry(ctx context.Context, endpoint string) error {
+	rc, _, status, err := c.Delete(ctx, endpoint)
+	closeBody(rc)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
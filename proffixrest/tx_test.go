@@ -0,0 +1,157 @@
+package proffixrest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClient_RunTx_Rollback(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr", "AUF/Dokument": "DokumentNr"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodPost && endpoint == "AUF/Dokument/1002/Position" {
+			writePxError(w, 422, "INVALID_FIELDS", "Artikel fehlt")
+			return true
+		}
+		return false
+	}
+	c := px.client(nil)
+
+	err := c.RunTx(ctx, func(tx *Tx) error {
+		_, header, _, err := tx.Post(ctx, "ADR/Adresse", map[string]interface{}{"Name": "Muster"})
+		if err != nil {
+			return err
+		}
+		adressNr := ConvertLocationToID(header)
+
+		_, header, _, err = tx.Post(ctx, "AUF/Dokument", map[string]interface{}{"Adresse": map[string]interface{}{"AdressNr": adressNr}})
+		if err != nil {
+			return err
+		}
+
+		_, _, _, err = tx.Post(ctx, "AUF/Dokument/"+ConvertLocationToID(header)+"/Position", map[string]interface{}{"Menge": 1})
+		return err
+	})
+
+	pxErr, ok := err.(*PxError)
+	if !ok || pxErr.Status != 422 {
+		t.Fatalf("Expected the error of the failed step, got %v", err)
+	}
+	if px.count("ADR/Adresse") != 0 || px.count("AUF/Dokument") != 0 {
+		t.Errorf("Expected address and document to be deleted")
+	}
+	if px.callCount("DELETE", "AUF/Dokument/1002") != 1 || px.callCount("DELETE", "ADR/Adresse/1001") != 1 {
+		t.Errorf("Expected compensations in reverse order")
+	}
+}
+
+func TestClient_RunTx_Commit(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	c := px.client(nil)
+
+	err := c.RunTx(ctx, func(tx *Tx) error {
+		_, _, _, err := tx.Post(ctx, "ADR/Adresse", map[string]interface{}{"Name": "Muster"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if px.count("ADR/Adresse") != 1 {
+		t.Errorf("Expected address to be kept")
+	}
+}
+
+func TestTx_RollbackPutAndPatch(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Bezeichnung1": "Schraube", "Preis": 1.5})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A2", "Bezeichnung1": "Mutter", "Preis": 0.5})
+	tx := px.client(nil).BeginTx()
+
+	if _, _, _, err := tx.Put(ctx, "LAG/Artikel/A1", map[string]interface{}{"ArtikelNr": "A1", "Bezeichnung1": "Neu"}); err != nil {
+		t.Fatalf("Expected no error on Put, got %v", err)
+	}
+	if _, _, _, err := tx.Patch(ctx, "LAG/Artikel/A2", map[string]interface{}{"Preis": 0.75, "Bemerkung": "neu"}); err != nil {
+		t.Fatalf("Expected no error on Patch, got %v", err)
+	}
+
+	// Concurrent change of another field is not overwritten by the Patch compensation
+	rec, _ := px.get("LAG/Artikel", "A2")
+	rec["Bezeichnung1"] = "Mutter M4"
+	px.put("LAG/Artikel", rec)
+
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Expected no error on Rollback, got %v", err)
+	}
+
+	rec, _ = px.get("LAG/Artikel", "A1")
+	if rec["Bezeichnung1"] != "Schraube" || rec["Preis"] != 1.5 {
+		t.Errorf("Expected A1 restored, got %v", rec)
+	}
+	rec, _ = px.get("LAG/Artikel", "A2")
+	if rec["Preis"] != 0.5 || rec["Bemerkung"] != nil || rec["Bezeichnung1"] != "Mutter M4" {
+		t.Errorf("Expected A2 patched fields restored, got %v", rec)
+	}
+
+	// Rollback is only done once
+	if err := tx.Rollback(ctx); err != nil || px.callCount("PUT", "LAG/Artikel/A1") != 2 {
+		t.Errorf("Expected second Rollback to do nothing")
+	}
+}
+
+func TestClient_RunTx_CompensationFails(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if r.Method == http.MethodDelete && endpoint == "ADR/Adresse/1001" {
+			writePxError(w, 409, "CONFLICT", "Adresse wird verwendet")
+			return true
+		}
+		return false
+	}
+	c := px.client(nil)
+
+	cause := errors.New("step failed")
+	err := c.RunTx(ctx, func(tx *Tx) error {
+		_, _, _, _ = tx.Post(ctx, "ADR/Adresse", map[string]interface{}{"Name": "Eins"})
+		_, _, _, _ = tx.Post(ctx, "ADR/Adresse", map[string]interface{}{"Name": "Zwei"})
+		return cause
+	})
+
+	var rbErr *RollbackError
+	if !errors.As(err, &rbErr) {
+		t.Fatalf("Expected RollbackError, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected RollbackError to wrap the cause")
+	}
+	if len(rbErr.Failed) != 1 || rbErr.Failed[0].Endpoint != "ADR/Adresse" || rbErr.Failed[0].Method != http.MethodPost {
+		t.Errorf("Unexpected failed compensations: %+v", rbErr.Failed)
+	}
+	if _, ok := px.get("ADR/Adresse", "1002"); ok {
+		t.Errorf("Expected second address to be deleted despite the failed compensation")
+	}
+}
+
+func TestClient_RunTx_Panic(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	c := px.client(nil)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic to be re-raised")
+		}
+		if px.count("ADR/Adresse") != 0 {
+			t.Errorf("Expected address to be deleted after panic")
+		}
+	}()
+
+	_ = c.RunTx(ctx, func(tx *Tx) error {
+		_, _, _, _ = tx.Post(ctx, "ADR/Adresse", map[string]interface{}{"Name": "Muster"})
+		panic("boom")
+	})
+}
@@ -0,0 +1,325 @@
+package proffixrest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WatchEventType is the type of a WatchEvent
+type WatchEventType string
+
+// Types of WatchEvent
+const (
+	WatchCreated WatchEventType = "created"
+	WatchUpdated WatchEventType = "updated"
+	WatchDeleted WatchEventType = "deleted"
+)
+
+// WatchEvent is a change of a single entry detected by a Watcher
+type WatchEvent struct {
+	Type          WatchEventType         `json:"Type"`
+	Key           string                 `json:"Key"`
+	Record        map[string]interface{} `json:"Record,omitempty"`        // Current entry (nil for deleted entries)
+	ChangedFields []string               `json:"ChangedFields,omitempty"` // Sorted fields which changed (updated entries only)
+}
+
+// WatchSnapshot holds a hash per field of every entry by key
+type WatchSnapshot map[string]map[string]string
+
+// SnapshotStore persists the snapshots of watchers by name
+type SnapshotStore interface {
+	// LoadSnapshot returns the snapshot of name; ok is false if there is none yet
+	LoadSnapshot(name string) (snapshot WatchSnapshot, ok bool, err error)
+	// SaveSnapshot stores the snapshot of name
+	SaveSnapshot(name string, snapshot WatchSnapshot) error
+}
+
+// MemorySnapshotStore keeps snapshots in memory
+type MemorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]WatchSnapshot
+}
+
+// NewMemorySnapshotStore creates an empty MemorySnapshotStore
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{snapshots: map[string]WatchSnapshot{}}
+}
+
+// LoadSnapshot returns the snapshot of name
+func (s *MemorySnapshotStore) LoadSnapshot(name string) (WatchSnapshot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, ok := s.snapshots[name]
+	return snapshot, ok, nil
+}
+
+// SaveSnapshot stores the snapshot of name
+func (s *MemorySnapshotStore) SaveSnapshot(name string, snapshot WatchSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[name] = snapshot
+	return nil
+}
+
+// FileSnapshotStore keeps one JSON file per snapshot in a directory
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore creates a FileSnapshotStore in dir; the directory is created on the first save
+func NewFileSnapshotStore(dir string) *FileSnapshotStore {
+	return &FileSnapshotStore{dir: dir}
+}
+
+// LoadSnapshot returns the snapshot of name
+func (s *FileSnapshotStore) LoadSnapshot(name string) (WatchSnapshot, bool, error) {
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var snapshot WatchSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, false, fmt.Errorf("invalid snapshot file %s: %w", s.path(name), err)
+	}
+	return snapshot, true, nil
+}
+
+// SaveSnapshot stores the snapshot of name
+func (s *FileSnapshotStore) SaveSnapshot(name string, snapshot WatchSnapshot) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(name), data, 0o600)
+}
+
+// path returns the file of a snapshot; the name is escaped so endpoints can be used
+func (s *FileSnapshotStore) path(name string) string {
+	return filepath.Join(s.dir, url.PathEscape(name)+".json")
+}
+
+// WatchOptions configures a Watcher
+type WatchOptions struct {
+	KeyField     string        // Key of the endpoint (required)
+	Query        url.Values    // Query of the watched entries, e.g. Filter
+	Interval     time.Duration // Polling interval of Run. Default is 1 minute
+	Store        SnapshotStore // Persists the snapshot. Default is a MemorySnapshotStore
+	Name         string        // Name of the snapshot in the store. Default is the endpoint
+	SkipInitial  bool          // The first poll without stored snapshot only records the entries and emits no events
+	IgnoreFields []string      // Fields which are not compared, e.g. "GeaendertAm"
+	OnError      func(error)   // Called by Run if a poll fails. Run continues with the next interval
+}
+
+// Watcher polls an endpoint and emits events for created, updated and deleted entries.
+// PROFFIX has no webhooks; changes are detected by comparing field hashes with the last snapshot.
+type Watcher struct {
+	client   *Client
+	endpoint string
+	opts     WatchOptions
+	ignore   map[string]bool
+}
+
+// NewWatcher creates a Watcher for endpoint.
+func (c *Client) NewWatcher(endpoint string, opts *WatchOptions) *Watcher {
+	w := &Watcher{client: c, endpoint: endpoint, ignore: map[string]bool{}}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = time.Minute
+	}
+	if w.opts.Store == nil {
+		w.opts.Store = NewMemorySnapshotStore()
+	}
+	if w.opts.Name == "" {
+		w.opts.Name = endpoint
+	}
+	for _, field := range w.opts.IgnoreFields {
+		w.ignore[field] = true
+	}
+	return w
+}
+
+// Poll reads the entries once, saves the new snapshot and returns the changes since the last poll.
+func (w *Watcher) Poll(ctx context.Context) ([]WatchEvent, error) {
+	events, snapshot, err := w.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.opts.Store.SaveSnapshot(w.opts.Name, snapshot); err != nil {
+		return nil, &PxError{Endpoint: w.endpoint, Message: fmt.Sprintf("Saving snapshot failed: %s", err)}
+	}
+	return events, nil
+}
+
+// Run polls immediately and then every Interval, calling fn for every event, until ctx is done.
+// The snapshot is saved after fn handled all events of a poll, so events are delivered at least once.
+func (w *Watcher) Run(ctx context.Context, fn func(WatchEvent)) error {
+	return w.run(ctx, func(event WatchEvent) error {
+		fn(event)
+		return nil
+	})
+}
+
+// run is Run with a handler which can reject the events of a poll
+func (w *Watcher) run(ctx context.Context, fn func(WatchEvent) error) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.runOnce(ctx, fn); err != nil && w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Events runs the Watcher in the background and returns its events on a channel which is closed when ctx is done.
+func (w *Watcher) Events(ctx context.Context) <-chan WatchEvent {
+	ch := make(chan WatchEvent)
+	go func() {
+		defer close(ch)
+		_ = w.Run(ctx, func(event WatchEvent) {
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return ch
+}
+
+// runOnce polls once, delivers the events and saves the snapshot.
+// If fn fails the snapshot is not saved, so the events are detected again by the next poll.
+func (w *Watcher) runOnce(ctx context.Context, fn func(WatchEvent) error) error {
+	events, snapshot, err := w.poll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		// Events may not have been delivered
+		return ctx.Err()
+	}
+	if err := w.opts.Store.SaveSnapshot(w.opts.Name, snapshot); err != nil {
+		return &PxError{Endpoint: w.endpoint, Message: fmt.Sprintf("Saving snapshot failed: %s", err)}
+	}
+	return nil
+}
+
+// poll reads all entries and compares them with the stored snapshot
+func (w *Watcher) poll(ctx context.Context) ([]WatchEvent, WatchSnapshot, error) {
+	previous, ok, err := w.opts.Store.LoadSnapshot(w.opts.Name)
+	if err != nil {
+		return nil, nil, &PxError{Endpoint: w.endpoint, Message: fmt.Sprintf("Loading snapshot failed: %s", err)}
+	}
+
+	// Deletions are only derived from a complete read; on any error the snapshot is kept
+	records, err := w.client.getAll(WithoutCache(ctx), w.endpoint, w.opts.KeyField, w.opts.Query, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot := make(WatchSnapshot, len(records))
+	byKey := make(map[string]map[string]interface{}, len(records))
+	for _, record := range records {
+		key := itemKey(record, w.opts.KeyField)
+		if key == "" {
+			continue
+		}
+		snapshot[key] = w.hashFields(record)
+		byKey[key] = record
+	}
+
+	// Without a stored snapshot every entry is new unless SkipInitial is set
+	if !ok && w.opts.SkipInitial {
+		return nil, snapshot, nil
+	}
+
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var events []WatchEvent
+	for _, key := range keys {
+		old, exists := previous[key]
+		if !exists {
+			events = append(events, WatchEvent{Type: WatchCreated, Key: key, Record: byKey[key]})
+			continue
+		}
+		if changed := changedFields(old, snapshot[key]); len(changed) > 0 {
+			events = append(events, WatchEvent{Type: WatchUpdated, Key: key, Record: byKey[key], ChangedFields: changed})
+		}
+	}
+
+	var deleted []string
+	for key := range previous {
+		if _, exists := snapshot[key]; !exists {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Strings(deleted)
+	for _, key := range deleted {
+		events = append(events, WatchEvent{Type: WatchDeleted, Key: key})
+	}
+
+	return events, snapshot, nil
+}
+
+// hashFields returns a short hash of every field of record
+func (w *Watcher) hashFields(record map[string]interface{}) map[string]string {
+	hashes := make(map[string]string, len(record))
+	for field, value := range record {
+		if w.ignore[field] {
+			continue
+		}
+		data, _ := json.Marshal(value)
+		sum := sha256.Sum256(data)
+		hashes[field] = hex.EncodeToString(sum[:8])
+	}
+	return hashes
+}
+
+// changedFields returns the sorted fields whose hashes differ
+func changedFields(old, current map[string]string) []string {
+	var changed []string
+	for field, hash := range current {
+		if old[field] != hash {
+			changed = append(changed, field)
+		}
+	}
+	for field := range old {
+		if _, exists := current[field]; !exists {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
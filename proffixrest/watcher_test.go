@@ -0,0 +1,196 @@
+package proffixrest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Poll(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 1, "Name": "Muster", "Ort": "Bern", "GeaendertAm": "2024-01-01 10:00:00"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 2, "Name": "EYX AG", "Ort": "Zürich"})
+
+	w := px.client(nil).NewWatcher("ADR/Adresse", &WatchOptions{KeyField: "AdressNr", IgnoreFields: []string{"GeaendertAm"}})
+
+	events, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(events) != 2 || events[0].Type != WatchCreated || events[0].Key != "1" || events[0].Record["Name"] != "Muster" {
+		t.Errorf("Expected 2 created events, got %+v", events)
+	}
+
+	// No change -> no events
+	if events, _ = w.Poll(ctx); len(events) != 0 {
+		t.Errorf("Expected no events, got %+v", events)
+	}
+
+	// Update, ignored field, delete and create
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 1, "Name": "Muster", "Ort": "Thun", "PLZ": "3600", "GeaendertAm": "2024-02-01 10:00:00"})
+	px.remove("ADR/Adresse", "2")
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 3, "Name": "Neu"})
+
+	events, err = w.Poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+	if e := events[0]; e.Type != WatchUpdated || e.Key != "1" || len(e.ChangedFields) != 2 || e.ChangedFields[0] != "Ort" || e.ChangedFields[1] != "PLZ" {
+		t.Errorf("Expected update of Ort and PLZ, got %+v", e)
+	}
+	if e := events[1]; e.Type != WatchCreated || e.Key != "3" {
+		t.Errorf("Expected created 3, got %+v", e)
+	}
+	if e := events[2]; e.Type != WatchDeleted || e.Key != "2" || e.Record != nil {
+		t.Errorf("Expected deleted 2, got %+v", e)
+	}
+}
+
+func TestWatcher_IncompleteReadKeepsSnapshot(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	for i := 1; i <= 3; i++ {
+		px.put("ADR/Adresse", map[string]interface{}{"AdressNr": i, "Name": "Muster"})
+	}
+	var mode atomic.Int32 // 1: no pxmetadata header, 2: second page fails
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		if endpoint != "ADR/Adresse" {
+			return false
+		}
+		switch mode.Load() {
+		case 1:
+			writeJSON(w, []map[string]interface{}{})
+			return true
+		case 2:
+			if strings.Contains(r.URL.Query().Get("Filter"), "AdressNr>") {
+				writePxError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "Server busy")
+				return true
+			}
+		}
+		return false
+	}
+
+	store := NewMemorySnapshotStore()
+	w := px.client(&Options{Batchsize: 2}).NewWatcher("ADR/Adresse", &WatchOptions{KeyField: "AdressNr", Store: store})
+	if events, err := w.Poll(ctx); err != nil || len(events) != 3 {
+		t.Fatalf("Expected 3 created events, got %+v (%v)", events, err)
+	}
+
+	for _, m := range []int32{1, 2} {
+		mode.Store(m)
+		events, err := w.Poll(ctx)
+		if err == nil || len(events) != 0 {
+			t.Errorf("Expected error and no events in mode %d, got %+v (%v)", m, events, err)
+		}
+		if snapshot, _, _ := store.LoadSnapshot("ADR/Adresse"); len(snapshot) != 3 {
+			t.Errorf("Expected snapshot of 3 entries to be kept in mode %d, got %d", m, len(snapshot))
+		}
+	}
+
+	mode.Store(0)
+	if events, err := w.Poll(ctx); err != nil || len(events) != 0 {
+		t.Errorf("Expected no events after recovery, got %+v (%v)", events, err)
+	}
+}
+
+func TestWatcher_DeleteBetweenPages(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	for i := 1; i <= 5; i++ {
+		px.put("ADR/Adresse", map[string]interface{}{"AdressNr": i, "Name": "Muster"})
+	}
+	var deleteOnNextPage atomic.Bool
+	px.handler = func(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+		// Entry 1 is deleted after the first page was read
+		if endpoint == "ADR/Adresse" && strings.Contains(r.URL.Query().Get("Filter"), "AdressNr>") && deleteOnNextPage.CompareAndSwap(true, false) {
+			px.remove("ADR/Adresse", "1")
+		}
+		return false
+	}
+
+	w := px.client(&Options{Batchsize: 2}).NewWatcher("ADR/Adresse", &WatchOptions{KeyField: "AdressNr"})
+	if events, err := w.Poll(ctx); err != nil || len(events) != 5 {
+		t.Fatalf("Expected 5 created events, got %+v (%v)", events, err)
+	}
+
+	// No entry after the deleted one is skipped
+	deleteOnNextPage.Store(true)
+	events, err := w.Poll(ctx)
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no events while entry 1 was read before its deletion, got %+v (%v)", events, err)
+	}
+
+	events, err = w.Poll(ctx)
+	if err != nil || len(events) != 1 || events[0].Type != WatchDeleted || events[0].Key != "1" {
+		t.Errorf("Expected only entry 1 deleted, got %+v (%v)", events, err)
+	}
+}
+
+func TestWatcher_PersistentSnapshot(t *testing.T) {
+	ctx := context.Background()
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Gruppe": "SHOP"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A2", "Gruppe": "B2B"})
+
+	query := url.Values{}
+	query.Set("Filter", "Gruppe=='SHOP'")
+	opts := &WatchOptions{KeyField: "ArtikelNr", Query: query, Store: NewFileSnapshotStore(t.TempDir()), SkipInitial: true}
+
+	// Initial poll only records the entries
+	events, err := px.client(nil).NewWatcher("LAG/Artikel", opts).Poll(ctx)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Expected no events on initial poll, got %+v (%v)", events, err)
+	}
+
+	// Restart with a new watcher: only real changes are emitted
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A3", "Gruppe": "SHOP"})
+	events, err = px.client(nil).NewWatcher("LAG/Artikel", opts).Poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(events) != 1 || events[0].Type != WatchCreated || events[0].Key != "A3" {
+		t.Errorf("Expected only A3 created after restart, got %+v", events)
+	}
+}
+
+func TestWatcher_Events(t *testing.T) {
+	px := newFakePX(t, map[string]string{"LAG/Artikel": "ArtikelNr"})
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Preis": 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemorySnapshotStore()
+	w := px.client(nil).NewWatcher("LAG/Artikel", &WatchOptions{KeyField: "ArtikelNr", Interval: 10 * time.Millisecond, SkipInitial: true, Store: store})
+	events := w.Events(ctx)
+
+	// Wait until the baseline is recorded, then change the entry
+	deadline := time.After(2 * time.Second)
+	for {
+		if _, ok, _ := store.LoadSnapshot("LAG/Artikel"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	px.put("LAG/Artikel", map[string]interface{}{"ArtikelNr": "A1", "Preis": 2})
+
+	select {
+	case e := <-events:
+		if e.Type != WatchUpdated || e.Key != "A1" || len(e.ChangedFields) != 1 || e.ChangedFields[0] != "Preis" {
+			t.Errorf("Unexpected event: %+v", e)
+		}
+	case <-deadline:
+		t.Fatalf("Expected an event")
+	}
+
+	cancel()
+	for range events {
+	}
+}
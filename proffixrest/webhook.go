@@ -0,0 +1,437 @@
+package proffixrest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of a webhook request
+const (
+	WebhookSignatureHeader = "X-Proffix-Signature" // "sha256=" followed by the hex HMAC-SHA256 of the body
+	WebhookEventHeader     = "X-Proffix-Event"     // Type of the event
+	WebhookDeliveryHeader  = "X-Proffix-Delivery"  // ID of the delivery; stays the same on retries
+)
+
+// WebhookTarget is a receiver of webhook requests
+type WebhookTarget struct {
+	Name   string           // Unique name of the target; deliveries in the outbox refer to it. Default is the URL
+	URL    string           // URL receiving the POST requests
+	Secret string           // Key of the HMAC signature. If empty no signature is sent
+	Events []WatchEventType // Types of events sent to the target. If nil all events are sent
+	Header http.Header      // Additional headers, e.g. Authorization
+}
+
+// WebhookOptions configures a WebhookDispatcher
+type WebhookOptions struct {
+	Targets     []WebhookTarget
+	OutboxDir   string        // Directory of the persistent outbox (required)
+	DeadLetter  string        // JSONL file of failed deliveries. Default is deadletter.jsonl in OutboxDir
+	MaxAttempts int           // Attempts per delivery before it is dead-lettered. Default is 8
+	Backoff     time.Duration // Delay after the first failed attempt; doubles on every attempt. Default is 1 second
+	MaxBackoff  time.Duration // Maximum delay between attempts. Default is 10 minutes
+	Interval    time.Duration // Interval in which Run flushes the outbox. Default is 5 seconds
+	HTTPClient  *http.Client  // Client for the requests. Default has a timeout of 15 seconds
+}
+
+// WebhookPayload is the JSON body of a webhook request
+type WebhookPayload struct {
+	ID       string     `json:"ID"`
+	Endpoint string     `json:"Endpoint"`
+	Time     time.Time  `json:"Time"`
+	Event    WatchEvent `json:"Event"`
+}
+
+// WebhookDelivery is a pending request to a single target, stored as file in the outbox
+type WebhookDelivery struct {
+	ID          string          `json:"ID"`
+	Target      string          `json:"Target"`
+	Event       WatchEventType  `json:"Event"`
+	Payload     json.RawMessage `json:"Payload"`
+	Attempts    int             `json:"Attempts"`
+	NextAttempt time.Time       `json:"NextAttempt"`
+	LastStatus  int             `json:"LastStatus,omitempty"`
+	LastError   string          `json:"LastError,omitempty"`
+
+	file string
+}
+
+// WebhookStats counts the deliveries processed by a Flush
+type WebhookStats struct {
+	Delivered    int // Sent successfully and removed from the outbox
+	Retried      int // Failed and scheduled for another attempt
+	DeadLettered int // Failed permanently and moved to the dead-letter file
+	Pending      int // Still in the outbox after the Flush
+}
+
+// WebhookDispatcher posts change events as signed JSON to the configured targets.
+// Events are written to the outbox before they are sent, so nothing is lost across restarts.
+// Deliveries to the same target are sent in order; a failing delivery holds back the later ones until it succeeds or is dead-lettered.
+type WebhookDispatcher struct {
+	opts    WebhookOptions
+	targets map[string]WebhookTarget
+	flushMu sync.Mutex
+	seqMu   sync.Mutex
+	seq     int64
+	notify  chan struct{}
+}
+
+// NewWebhookDispatcher creates a WebhookDispatcher and its outbox directory.
+func NewWebhookDispatcher(opts *WebhookOptions) (*WebhookDispatcher, error) {
+	d := &WebhookDispatcher{targets: map[string]WebhookTarget{}, notify: make(chan struct{}, 1)}
+	if opts != nil {
+		d.opts = *opts
+	}
+	if d.opts.OutboxDir == "" {
+		return nil, &PxError{Message: "WebhookOptions.OutboxDir is required"}
+	}
+	if d.opts.DeadLetter == "" {
+		d.opts.DeadLetter = filepath.Join(d.opts.OutboxDir, "deadletter.jsonl")
+	}
+	if d.opts.MaxAttempts <= 0 {
+		d.opts.MaxAttempts = 8
+	}
+	if d.opts.Backoff <= 0 {
+		d.opts.Backoff = time.Second
+	}
+	if d.opts.MaxBackoff <= 0 {
+		d.opts.MaxBackoff = 10 * time.Minute
+	}
+	if d.opts.Interval <= 0 {
+		d.opts.Interval = 5 * time.Second
+	}
+	if d.opts.HTTPClient == nil {
+		d.opts.HTTPClient = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	for _, target := range d.opts.Targets {
+		if target.Name == "" {
+			target.Name = target.URL
+		}
+		if target.URL == "" {
+			return nil, &PxError{Message: fmt.Sprintf("Webhook target %q has no URL", target.Name)}
+		}
+		if _, exists := d.targets[target.Name]; exists {
+			return nil, &PxError{Message: fmt.Sprintf("Webhook target %q is configured twice", target.Name)}
+		}
+		d.targets[target.Name] = target
+	}
+
+	if err := os.MkdirAll(d.opts.OutboxDir, 0o750); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Enqueue writes a delivery of event to the outbox for every target subscribed to its type.
+func (d *WebhookDispatcher) Enqueue(endpoint string, event WatchEvent) error {
+	id, err := randomID()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(WebhookPayload{ID: id, Endpoint: endpoint, Time: time.Now().UTC(), Event: event})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(d.targets))
+	for name := range d.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !subscribed(d.targets[name], event.Type) {
+			continue
+		}
+		delivery := &WebhookDelivery{ID: id, Target: name, Event: event.Type, Payload: payload}
+		delivery.file = filepath.Join(d.opts.OutboxDir, d.nextFileName())
+		if err := d.save(delivery); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Watch runs w and enqueues all of its events until ctx is done.
+// The snapshot of w is only saved after the events are in the outbox.
+func (d *WebhookDispatcher) Watch(ctx context.Context, w *Watcher) error {
+	return w.run(ctx, func(event WatchEvent) error {
+		return d.Enqueue(w.endpoint, event)
+	})
+}
+
+// Run flushes the outbox every Interval and right after new events were enqueued, until ctx is done.
+// Errors of a Flush are retried with the next one.
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = d.Flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-d.notify:
+		}
+	}
+}
+
+// Flush sends all deliveries of the outbox which are due.
+func (d *WebhookDispatcher) Flush(ctx context.Context) (WebhookStats, error) {
+	d.flushMu.Lock()
+	defer d.flushMu.Unlock()
+
+	var stats WebhookStats
+	deliveries, err := d.Outbox()
+	if err != nil {
+		return stats, err
+	}
+
+	blocked := map[string]bool{}
+	now := time.Now()
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			stats.Pending++
+			continue
+		}
+		if blocked[delivery.Target] || delivery.NextAttempt.After(now) {
+			blocked[delivery.Target] = true
+			stats.Pending++
+			continue
+		}
+
+		target, ok := d.targets[delivery.Target]
+		if !ok {
+			delivery.LastError = "unknown target"
+			if err := d.deadLetter(delivery); err != nil {
+				return stats, err
+			}
+			stats.DeadLettered++
+			continue
+		}
+
+		status, sendErr := d.send(ctx, target, delivery)
+		delivery.Attempts++
+		delivery.LastStatus = status
+
+		switch {
+		case sendErr == nil:
+			if err := os.Remove(delivery.file); err != nil && !os.IsNotExist(err) {
+				return stats, err
+			}
+			stats.Delivered++
+		case permanentStatus(status) || delivery.Attempts >= d.opts.MaxAttempts:
+			delivery.LastError = sendErr.Error()
+			if err := d.deadLetter(delivery); err != nil {
+				return stats, err
+			}
+			stats.DeadLettered++
+		default:
+			delivery.LastError = sendErr.Error()
+			delivery.NextAttempt = time.Now().Add(d.backoff(delivery.Attempts))
+			if err := d.save(delivery); err != nil {
+				return stats, err
+			}
+			blocked[delivery.Target] = true
+			stats.Retried++
+			stats.Pending++
+		}
+	}
+	return stats, nil
+}
+
+// Outbox returns the pending deliveries in the order they were enqueued
+func (d *WebhookDispatcher) Outbox() ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := readOutbox(d.opts.OutboxDir, func(path string, data []byte) error {
+		var delivery WebhookDelivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return fmt.Errorf("invalid outbox file %s: %w", path, err)
+		}
+		delivery.file = path
+		deliveries = append(deliveries, &delivery)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// readOutbox calls fn for every .json file in dir in the order the files were enqueued
+func readOutbox(dir string, fn func(path string, data []byte) error) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	// File names start with the enqueue time
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path) // #nosec G304 -- file of the configured outbox
+		if err != nil {
+			return err
+		}
+		if err := fn(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send posts a delivery to its target
+func (d *WebhookDispatcher) send(ctx context.Context, target WebhookTarget, delivery *WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range target.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	if target.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(target.Secret, delivery.Payload))
+	}
+
+	resp, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("target %s responded with status %d", target.Name, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// save writes a delivery to its outbox file
+func (d *WebhookDispatcher) save(delivery *WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(delivery.file, data, 0o600)
+}
+
+// deadLetter appends a delivery to the dead-letter file and removes it from the outbox
+func (d *WebhookDispatcher) deadLetter(delivery *WebhookDelivery) error {
+	line, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(d.opts.DeadLetter, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600) // #nosec G304 -- caller controls dead-letter path by design
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(delivery.file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.Backoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.opts.MaxBackoff {
+		delay = d.opts.MaxBackoff
+	}
+	return delay
+}
+
+// nextFileName returns a unique outbox file name which sorts in enqueue order
+func (d *WebhookDispatcher) nextFileName() string {
+	d.seqMu.Lock()
+	defer d.seqMu.Unlock()
+	d.seq++
+	return outboxFileName(d.seq)
+}
+
+// outboxFileName returns a file name starting with the current time, so names sort in creation order
+func outboxFileName(seq int64) string {
+	return fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), seq%1000000)
+}
+
+// SignWebhook returns the signature header value of a payload: "sha256=" followed by the hex HMAC-SHA256.
+// Receivers compare it with hmac.Equal to verify a request.
+func SignWebhook(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscribed checks if target receives events of typ
+func subscribed(target WebhookTarget, typ WatchEventType) bool {
+	if target.Events == nil {
+		return true
+	}
+	for _, t := range target.Events {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// permanentStatus checks if a status means the request will never succeed
+func permanentStatus(status int) bool {
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// randomID returns a random hex ID
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
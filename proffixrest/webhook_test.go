@@ -0,0 +1,277 @@
+package proffixrest
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookTarget is a local target recording the received requests
+type webhookTarget struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int // Responses of the next requests; 200 when empty
+}
+
+func newWebhookTarget(t *testing.T, statuses ...int) *webhookTarget {
+	t.Helper()
+	target := &webhookTarget{statuses: statuses}
+	target.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		target.mu.Lock()
+		status := http.StatusOK
+		if len(target.statuses) > 0 {
+			status, target.statuses = target.statuses[0], target.statuses[1:]
+		}
+		if status == http.StatusOK {
+			target.bodies = append(target.bodies, body)
+			target.headers = append(target.headers, r.Header.Clone())
+		}
+		target.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(target.Close)
+	return target
+}
+
+// received returns the payloads received so far
+func (target *webhookTarget) received(t *testing.T) []WebhookPayload {
+	t.Helper()
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	payloads := make([]WebhookPayload, len(target.bodies))
+	for i, body := range target.bodies {
+		if err := json.Unmarshal(body, &payloads[i]); err != nil {
+			t.Fatalf("Invalid payload: %v", err)
+		}
+	}
+	return payloads
+}
+
+func TestWebhookDispatcher_DeliversSignedPayload(t *testing.T) {
+	target := newWebhookTarget(t)
+	other := newWebhookTarget(t)
+	d, err := NewWebhookDispatcher(&WebhookOptions{
+		OutboxDir: t.TempDir(),
+		Targets: []WebhookTarget{
+			{Name: "erp", URL: target.URL, Secret: "geheim", Header: http.Header{"Authorization": {"Bearer x"}}},
+			{Name: "shop", URL: other.URL, Events: []WatchEventType{WatchDeleted}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	event := WatchEvent{Type: WatchUpdated, Key: "1", Record: map[string]interface{}{"AdressNr": 1}, ChangedFields: []string{"Name"}}
+	if err := d.Enqueue("ADR/Adresse", event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stats, err := d.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats.Delivered != 1 || stats.Pending != 0 {
+		t.Errorf("Expected 1 delivered and 0 pending, got %+v", stats)
+	}
+	if len(other.received(t)) != 0 {
+		t.Errorf("Expected no request to the target not subscribed to updates")
+	}
+
+	payloads := target.received(t)
+	if len(payloads) != 1 {
+		t.Fatalf("Expected 1 payload, got %d", len(payloads))
+	}
+	if payloads[0].Endpoint != "ADR/Adresse" || payloads[0].Event.Key != "1" || payloads[0].Event.Type != WatchUpdated {
+		t.Errorf("Expected update of ADR/Adresse 1, got %+v", payloads[0])
+	}
+
+	header := target.headers[0]
+	if !hmac.Equal([]byte(header.Get(WebhookSignatureHeader)), []byte(SignWebhook("geheim", target.bodies[0]))) {
+		t.Errorf("Expected valid signature, got %s", header.Get(WebhookSignatureHeader))
+	}
+	if header.Get(WebhookDeliveryHeader) != payloads[0].ID {
+		t.Errorf("Expected delivery header %s, got %s", payloads[0].ID, header.Get(WebhookDeliveryHeader))
+	}
+	if header.Get(WebhookEventHeader) != "updated" || header.Get("Authorization") != "Bearer x" {
+		t.Errorf("Expected event and custom headers, got %v", header)
+	}
+}
+
+func TestWebhookDispatcher_RetriesInOrder(t *testing.T) {
+	target := newWebhookTarget(t, http.StatusServiceUnavailable, http.StatusInternalServerError)
+	d, err := NewWebhookDispatcher(&WebhookOptions{
+		OutboxDir: t.TempDir(),
+		Targets:   []WebhookTarget{{URL: target.URL}},
+		Backoff:   time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, key := range []string{"1", "2", "3"} {
+		if err := d.Enqueue("ADR/Adresse", WatchEvent{Type: WatchCreated, Key: key}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// The first delivery fails and holds back the others
+	stats, _ := d.Flush(context.Background())
+	if stats.Retried != 1 || stats.Pending != 3 || stats.Delivered != 0 {
+		t.Errorf("Expected 1 retried and 3 pending, got %+v", stats)
+	}
+	outbox, _ := d.Outbox()
+	if outbox[0].Attempts != 1 || outbox[0].LastStatus != http.StatusServiceUnavailable || outbox[0].NextAttempt.IsZero() {
+		t.Errorf("Expected first delivery with 1 attempt and next attempt, got %+v", outbox[0])
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats, err = d.Flush(context.Background())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if stats.Pending == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	payloads := target.received(t)
+	if len(payloads) != 3 {
+		t.Fatalf("Expected 3 payloads, got %d", len(payloads))
+	}
+	for i, key := range []string{"1", "2", "3"} {
+		if payloads[i].Event.Key != key {
+			t.Errorf("Expected key %s at %d, got %s", key, i, payloads[i].Event.Key)
+		}
+	}
+}
+
+func TestWebhookDispatcher_DeadLetter(t *testing.T) {
+	rejecting := newWebhookTarget(t, http.StatusBadRequest)
+	failing := newWebhookTarget(t, http.StatusBadGateway, http.StatusBadGateway)
+	dir := t.TempDir()
+	d, err := NewWebhookDispatcher(&WebhookOptions{
+		OutboxDir:   dir,
+		Targets:     []WebhookTarget{{Name: "rejecting", URL: rejecting.URL}, {Name: "failing", URL: failing.URL}},
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := d.Enqueue("ADR/Adresse", WatchEvent{Type: WatchDeleted, Key: "7"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stats, _ := d.Flush(context.Background())
+	if stats.DeadLettered != 1 || stats.Retried != 1 {
+		t.Errorf("Expected client error dead-lettered at once and 1 retry, got %+v", stats)
+	}
+	time.Sleep(5 * time.Millisecond)
+	stats, _ = d.Flush(context.Background())
+	if stats.DeadLettered != 1 || stats.Pending != 0 {
+		t.Errorf("Expected dead-letter after max attempts, got %+v", stats)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "deadletter.jsonl"))
+	if err != nil {
+		t.Fatalf("Expected dead-letter file, got %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var letters []WebhookDelivery
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var delivery WebhookDelivery
+		if err := json.Unmarshal(scanner.Bytes(), &delivery); err != nil {
+			t.Fatalf("Invalid dead-letter line: %v", err)
+		}
+		letters = append(letters, delivery)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(letters))
+	}
+	if letters[0].Target != "rejecting" || letters[0].LastStatus != http.StatusBadRequest || letters[0].Attempts != 1 {
+		t.Errorf("Expected rejected delivery after 1 attempt, got %+v", letters[0])
+	}
+	if letters[1].Target != "failing" || letters[1].Attempts != 2 || letters[1].LastError == "" {
+		t.Errorf("Expected failing delivery after 2 attempts, got %+v", letters[1])
+	}
+}
+
+func TestWebhookDispatcher_OutboxSurvivesRestart(t *testing.T) {
+	target := newWebhookTarget(t)
+	dir := t.TempDir()
+	opts := &WebhookOptions{OutboxDir: dir, Targets: []WebhookTarget{{URL: target.URL}}}
+
+	first, err := NewWebhookDispatcher(opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = first.Enqueue("LAG/Artikel", WatchEvent{Type: WatchCreated, Key: "A1"})
+	_ = first.Enqueue("LAG/Artikel", WatchEvent{Type: WatchCreated, Key: "A2"})
+
+	second, err := NewWebhookDispatcher(opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats, _ := second.Flush(context.Background()); stats.Delivered != 2 {
+		t.Errorf("Expected 2 delivered after restart, got %+v", stats)
+	}
+	if payloads := target.received(t); len(payloads) != 2 || payloads[0].Event.Key != "A1" {
+		t.Errorf("Expected A1 and A2 in order, got %+v", payloads)
+	}
+}
+
+func TestWebhookDispatcher_Watch(t *testing.T) {
+	px := newFakePX(t, map[string]string{"ADR/Adresse": "AdressNr"})
+	px.put("ADR/Adresse", map[string]interface{}{"AdressNr": 1, "Name": "Muster AG"})
+	target := newWebhookTarget(t)
+
+	d, err := NewWebhookDispatcher(&WebhookOptions{OutboxDir: t.TempDir(), Targets: []WebhookTarget{{URL: target.URL}}, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w := px.client(nil).NewWatcher("ADR/Adresse", &WatchOptions{KeyField: "AdressNr", Interval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = d.Run(ctx) }()
+	go func() { _ = d.Watch(ctx, w) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(target.received(t)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	payloads := target.received(t)
+	if len(payloads) != 1 || payloads[0].Event.Type != WatchCreated || payloads[0].Endpoint != "ADR/Adresse" {
+		t.Errorf("Expected created event of ADR/Adresse, got %+v", payloads)
+	}
+}
+
+func TestNewWebhookDispatcher_Validation(t *testing.T) {
+	if _, err := NewWebhookDispatcher(nil); err == nil {
+		t.Errorf("Expected error without OutboxDir")
+	}
+	dir := t.TempDir()
+	if _, err := NewWebhookDispatcher(&WebhookOptions{OutboxDir: dir, Targets: []WebhookTarget{{Name: "x"}}}); err == nil {
+		t.Errorf("Expected error for target without URL")
+	}
+	if _, err := NewWebhookDispatcher(&WebhookOptions{OutboxDir: dir, Targets: []WebhookTarget{{URL: "http://a"}, {URL: "http://a"}}}); err == nil {
+		t.Errorf("Expected error for duplicate target")
+	}
+}